@@ -0,0 +1,39 @@
+package configwatcher
+
+import "errors"
+
+// WithApplier registers fn to put each new configuration into effect, for
+// example by reconnecting a database pool, before it is stored and
+// broadcast. Appliers run in registration order with the current and the
+// candidate value whenever the configuration changes, including on the
+// initial load. If one returns an error, the candidate is discarded: the
+// appliers that already succeeded are called again with the values swapped
+// to undo their work, the previous configuration stays in effect and the
+// load fails with an *ApplyError. A Save whose value fails to apply also
+// puts the previous configuration back into the file.
+//
+// Appliers run while the watcher holds its lock, so they may call Get but
+// must not call Save, Update, CompareAndSave or Reload.
+func WithApplier[T any](fn func(old, new T) error) Option[T] {
+	return func(w *Watcher[T]) { w.appliers = append(w.appliers, fn) }
+}
+
+// apply runs the appliers for a change from old to newVal, rolling back the
+// ones that succeeded if any fails. Callers must hold w.mu.
+func (w *Watcher[T]) apply(old, newVal T) error {
+	if len(w.appliers) == 0 || w.equal(old, newVal) {
+		return nil
+	}
+	for i, fn := range w.appliers {
+		err := fn(old, newVal)
+		if err == nil {
+			continue
+		}
+		var rollback []error
+		for j := i - 1; j >= 0; j-- {
+			rollback = append(rollback, w.appliers[j](newVal, old))
+		}
+		return &ApplyError{Path: w.filename, Applier: i, Err: err, Rollback: errors.Join(rollback...)}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package configwatcher
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type applyConfig struct {
+	DSN string `json:"dsn"`
+}
+
+// applyLog records applier calls as "name:old->new".
+type applyLog struct {
+	calls []string
+}
+
+func (l *applyLog) applier(name string, fail string) func(old, new applyConfig) error {
+	return func(old, new applyConfig) error {
+		l.calls = append(l.calls, name+":"+old.DSN+"->"+new.DSN)
+		if new.DSN == fail {
+			return errors.New(name + " cannot connect")
+		}
+		return nil
+	}
+}
+
+func TestWithApplier(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	writeRaw(t, configFile, `{"dsn": "a"}`)
+	log := &applyLog{}
+
+	watcher, err := New(applyConfig{}, configFile,
+		WithApplier(log.applier("pool", "")), WithApplier(log.applier("cache", "")))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	if err := watcher.Save(applyConfig{DSN: "b"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	want := []string{"pool:->a", "cache:->a", "pool:a->b", "cache:a->b"}
+	if !reflect.DeepEqual(log.calls, want) {
+		t.Errorf("Expected calls %v, got %v", want, log.calls)
+	}
+}
+
+func TestApplierRollback(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	writeRaw(t, configFile, `{"dsn": "a"}`)
+	log := &applyLog{}
+
+	watcher, err := New(applyConfig{}, configFile,
+		WithApplier(log.applier("pool", "")), WithApplier(log.applier("cache", "bad")),
+		WithApplier(log.applier("metrics", "")))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+	log.calls = nil
+	rev := watcher.Revision()
+
+	err = watcher.Save(applyConfig{DSN: "bad"})
+	var applyErr *ApplyError
+	if !errors.As(err, &applyErr) || applyErr.Applier != 1 {
+		t.Fatalf("Expected *ApplyError from applier 1, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "cache cannot connect") {
+		t.Errorf("Expected the applier's error in the message, got %q", err)
+	}
+	want := []string{"pool:a->bad", "cache:a->bad", "pool:bad->a"}
+	if !reflect.DeepEqual(log.calls, want) {
+		t.Errorf("Expected calls %v, got %v", want, log.calls)
+	}
+	if got := watcher.Get().DSN; got != "a" {
+		t.Errorf("Expected previous value to be kept, got %q", got)
+	}
+	if got := watcher.Revision(); got != rev {
+		t.Errorf("Expected revision %d to be unchanged, got %d", rev, got)
+	}
+	if data, _ := os.ReadFile(configFile); !strings.Contains(string(data), `"a"`) {
+		t.Errorf("Expected the file to be restored, got %s", data)
+	}
+}
+
+func TestApplierRollbackError(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	writeRaw(t, configFile, `{"dsn": "a"}`)
+
+	watcher, err := New(applyConfig{}, configFile,
+		WithApplier(func(old, new applyConfig) error {
+			if old.DSN == "bad" {
+				return errors.New("undo failed")
+			}
+			return nil
+		}),
+		WithApplier(func(old, new applyConfig) error {
+			if new.DSN == "bad" {
+				return errors.New("apply failed")
+			}
+			return nil
+		}))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	writeRaw(t, configFile, `{"dsn": "bad"}`)
+	err = watcher.Reload()
+	var applyErr *ApplyError
+	if !errors.As(err, &applyErr) || applyErr.Rollback == nil {
+		t.Fatalf("Expected *ApplyError with a rollback error, got %v", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "rollback: undo failed") {
+		t.Errorf("Expected rollback error in message, got %q", msg)
+	}
+	if got := watcher.Get().DSN; got != "a" {
+		t.Errorf("Expected previous value to be kept, got %q", got)
+	}
+}
@@ -0,0 +1,63 @@
+package configwatcher
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// defaultFileMode is used for files created by the watcher.
+const defaultFileMode fs.FileMode = 0o600
+
+// writeAtomic replaces filename with data so that readers never observe a
+// partially written file. The data is written to a sibling temp file, which
+// is fsynced and renamed over the target, and the directory is fsynced so
+// the rename survives a crash. An existing file keeps its permission bits
+// and, where the platform allows, its ownership. If filename is a symlink,
+// its target is replaced and the link is left intact.
+func writeAtomic(filename string, data []byte) (err error) {
+	target := filename
+	if resolved, rerr := filepath.EvalSymlinks(filename); rerr == nil {
+		target = resolved
+	}
+	mode := defaultFileMode
+	info, statErr := os.Stat(target)
+	if statErr == nil {
+		mode = info.Mode().Perm()
+	} else if !errors.Is(statErr, fs.ErrNotExist) {
+		return statErr
+	}
+
+	dir := filepath.Dir(target)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(target)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if statErr == nil {
+		preserveOwner(tmp, info)
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), target); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
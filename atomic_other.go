@@ -0,0 +1,14 @@
+//go:build !unix
+
+package configwatcher
+
+import (
+	"io/fs"
+	"os"
+)
+
+// preserveOwner is a no-op on platforms without POSIX ownership.
+func preserveOwner(*os.File, fs.FileInfo) {}
+
+// syncDir is a no-op on platforms that cannot fsync a directory.
+func syncDir(string) error { return nil }
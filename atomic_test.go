@@ -0,0 +1,86 @@
+package configwatcher
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteAtomicCreatesWithDefaultMode(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+
+	if err := writeAtomic(configFile, []byte(`{}`)); err != nil {
+		t.Fatalf("writeAtomic failed: %v", err)
+	}
+	info, err := os.Stat(configFile)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != defaultFileMode {
+		t.Errorf("Expected mode %v, got %v", defaultFileMode, info.Mode().Perm())
+	}
+}
+
+func TestSavePreservesModeAndLeavesNoTempFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not preserved on Windows")
+	}
+	configFile := createTempConfigFile(t, TestConfig{Name: "test", Count: 1})
+	if err := os.Chmod(configFile, 0o640); err != nil {
+		t.Fatalf("Chmod failed: %v", err)
+	}
+
+	watcher := NewWatcher(TestConfig{}, configFile)
+	defer watcher.Close()
+
+	if err := watcher.Save(TestConfig{Name: "saved", Count: 2}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	info, err := os.Stat(configFile)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Mode().Perm() != 0o640 {
+		t.Errorf("Expected mode 0640 to be preserved, got %v", info.Mode().Perm())
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(configFile))
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the config file to remain, got %d entries", len(entries))
+	}
+}
+
+func TestWriteAtomicFollowsSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require privileges on Windows")
+	}
+	dir := t.TempDir()
+	target := filepath.Join(dir, "real.json")
+	link := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(target, []byte(`{}`), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatalf("Symlink failed: %v", err)
+	}
+
+	if err := writeAtomic(link, []byte(`{"name":"x"}`)); err != nil {
+		t.Fatalf("writeAtomic failed: %v", err)
+	}
+
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("Expected %s to remain a symlink", link)
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(data) != `{"name":"x"}` {
+		t.Errorf("Expected target to be updated, got %q", data)
+	}
+}
@@ -0,0 +1,27 @@
+//go:build unix
+
+package configwatcher
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// preserveOwner copies the owner and group of info to f. Failures are
+// ignored: an unprivileged process can only keep ownership it already has.
+func preserveOwner(f *os.File, info fs.FileInfo) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		_ = f.Chown(int(st.Uid), int(st.Gid))
+	}
+}
+
+// syncDir flushes directory metadata so a completed rename is durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,142 @@
+package configwatcher
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Cause describes what triggered a configuration change.
+type Cause int
+
+const (
+	// CauseInitial is the load performed when the watcher is created.
+	CauseInitial Cause = iota + 1
+	// CauseFileEvent is a reload after the file changed on disk.
+	CauseFileEvent
+	// CauseSave is a value written through Save.
+	CauseSave
+	// CauseReload is a reload requested through Reload.
+	CauseReload
+)
+
+// String returns a lower-case name for the cause.
+func (c Cause) String() string {
+	switch c {
+	case CauseInitial:
+		return "initial"
+	case CauseFileEvent:
+		return "file"
+	case CauseSave:
+		return "save"
+	case CauseReload:
+		return "reload"
+	default:
+		return "unknown"
+	}
+}
+
+// Change describes a configuration change. Revision increases by one for
+// every accepted change, so gaps never occur for a single subscriber.
+// Delta lists the values that differ between Old and New.
+type Change[T any] struct {
+	Old      T
+	New      T
+	Delta    Delta
+	Revision uint64
+	Time     time.Time
+	Cause    Cause
+}
+
+// SubscribeChanges returns a channel that receives every configuration
+// change in order, carrying the previous and new values. Changes are
+// queued per subscriber and never dropped; a slow reader only delays its
+// own channel. The channel is closed when ctx is done or the watcher is
+// closed.
+func (w *Watcher[T]) SubscribeChanges(ctx context.Context) <-chan Change[T] {
+	ctx = w.scope(ctx)
+	out := make(chan Change[T])
+	q := &changeQueue[T]{notify: make(chan struct{}, 1)}
+	remove := w.addListener(q.push)
+	go func() {
+		defer close(out)
+		defer remove()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.notify:
+			}
+			for c, ok := q.pop(); ok; c, ok = q.pop() {
+				select {
+				case out <- c:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// Revision returns the revision of the current value. It starts at zero
+// for the default value and increases with every accepted change.
+func (w *Watcher[T]) Revision() uint64 {
+	return w.revision.Load()
+}
+
+// addListener registers fn to be called, with w.mu held, for every
+// accepted change. The returned function unregisters it.
+func (w *Watcher[T]) addListener(fn func(Change[T])) (remove func()) {
+	w.listenersMu.Lock()
+	defer w.listenersMu.Unlock()
+	if w.listeners == nil {
+		w.listeners = make(map[uint64]func(Change[T]))
+	}
+	w.nextListener++
+	id := w.nextListener
+	w.listeners[id] = fn
+	return func() {
+		w.listenersMu.Lock()
+		defer w.listenersMu.Unlock()
+		delete(w.listeners, id)
+	}
+}
+
+// publish delivers c to every registered listener.
+func (w *Watcher[T]) publish(c Change[T]) {
+	w.listenersMu.RLock()
+	defer w.listenersMu.RUnlock()
+	for _, fn := range w.listeners {
+		fn(c)
+	}
+}
+
+// changeQueue is an unbounded FIFO feeding one SubscribeChanges channel.
+type changeQueue[T any] struct {
+	mu     sync.Mutex
+	items  []Change[T]
+	notify chan struct{}
+}
+
+func (q *changeQueue[T]) push(c Change[T]) {
+	q.mu.Lock()
+	q.items = append(q.items, c)
+	q.mu.Unlock()
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (q *changeQueue[T]) pop() (Change[T], bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		var zero Change[T]
+		return zero, false
+	}
+	c := q.items[0]
+	q.items = q.items[1:]
+	return c, true
+}
@@ -0,0 +1,133 @@
+package configwatcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func receiveChange[T any](t *testing.T, ch <-chan Change[T]) Change[T] {
+	t.Helper()
+	select {
+	case c, ok := <-ch:
+		if !ok {
+			t.Fatal("Change channel closed unexpectedly")
+		}
+		return c
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for change")
+	}
+	panic("unreachable")
+}
+
+func TestSubscribeChanges(t *testing.T) {
+	configFile := createTempConfigFile(t, TestConfig{Name: "initial", Count: 1})
+	watcher, err := New(TestConfig{}, configFile)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	if rev := watcher.Revision(); rev != 1 {
+		t.Errorf("Expected revision 1 after initial load, got %d", rev)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := watcher.SubscribeChanges(ctx)
+
+	if err := watcher.Save(TestConfig{Name: "saved", Count: 2}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	c := receiveChange(t, changes)
+	if c.Old.Name != "initial" || c.New.Name != "saved" {
+		t.Errorf("Unexpected change values: old=%+v new=%+v", c.Old, c.New)
+	}
+	if c.Revision != 2 || c.Cause != CauseSave {
+		t.Errorf("Expected revision 2 caused by save, got %d %v", c.Revision, c.Cause)
+	}
+	if c.Time.IsZero() {
+		t.Error("Expected change timestamp")
+	}
+
+	writeJSON(t, configFile, TestConfig{Name: "external", Count: 3})
+	c = receiveChange(t, changes)
+	if c.Old.Name != "saved" || c.New.Name != "external" || c.Cause != CauseFileEvent {
+		t.Errorf("Unexpected file change: %+v", c)
+	}
+}
+
+func TestSubscribeChangesInOrderWithoutDrops(t *testing.T) {
+	configFile := createTempConfigFile(t, TestConfig{Name: "ordered"})
+	watcher, err := New(TestConfig{}, configFile)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := watcher.SubscribeChanges(ctx)
+
+	const n = 20
+	for i := 1; i <= n; i++ {
+		if err := watcher.Save(TestConfig{Name: "ordered", Count: i}); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	// The reader only starts after all saves, so every change was queued.
+	prev := receiveChange(t, changes)
+	for i := 2; i <= n; i++ {
+		c := receiveChange(t, changes)
+		if c.Revision != prev.Revision+1 {
+			t.Fatalf("Expected revision %d, got %d", prev.Revision+1, c.Revision)
+		}
+		if c.Old.Count != prev.New.Count {
+			t.Errorf("Change %d does not follow the previous one: %+v after %+v", i, c, prev)
+		}
+		prev = c
+	}
+	if prev.New.Count != n {
+		t.Errorf("Expected last change to hold count %d, got %d", n, prev.New.Count)
+	}
+}
+
+func TestSubscribeChangesClosedOnClose(t *testing.T) {
+	configFile := createTempConfigFile(t, TestConfig{Name: "close"})
+	watcher := NewWatcher(TestConfig{}, configFile)
+	changes := watcher.SubscribeChanges(context.Background())
+
+	watcher.Close()
+
+	select {
+	case _, ok := <-changes:
+		if ok {
+			t.Error("Expected change channel to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for change channel to close")
+	}
+}
+
+func TestReload(t *testing.T) {
+	configFile := createTempConfigFile(t, TestConfig{Name: "initial"})
+	watcher, err := New(TestConfig{}, configFile)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	writeJSON(t, configFile, TestConfig{Name: "reloaded"})
+	if err := watcher.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if got := watcher.Get(); got.Name != "reloaded" {
+		t.Errorf("Expected reloaded config, got %+v", got)
+	}
+
+	watcher.Close()
+	if err := watcher.Reload(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+}
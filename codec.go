@@ -0,0 +1,156 @@
+package configwatcher
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	jsoncodec "github.com/blackorder/configwatcher/codec/json"
+)
+
+// Codec encodes and decodes configuration files. Implementations for JSON,
+// YAML and TOML live in the codec subpackages.
+type Codec interface {
+	// Marshal encodes v into the file format.
+	Marshal(v any) ([]byte, error)
+	// Unmarshal decodes data into v.
+	Unmarshal(data []byte, v any) error
+	// Extensions lists the file extensions, including the leading dot,
+	// that the codec handles.
+	Extensions() []string
+}
+
+// Patcher is implemented by codecs that can write a value into an existing
+// document in place. When the codec supports it, Save patches the changed
+// values into the current file instead of re-encoding it, so comments, key
+// order and formatting written by humans are preserved.
+type Patcher interface {
+	// Patch returns original updated to hold v.
+	Patch(original []byte, v any) ([]byte, error)
+}
+
+// FieldTagger is implemented by codecs that name struct fields with a tag
+// other than "json". Features that map file keys back to struct fields,
+// such as WithStrict, use it to find the key names.
+type FieldTagger interface {
+	// FieldTag returns the struct tag key the codec reads, such as "yaml".
+	FieldTag() string
+}
+
+// fieldTag returns the struct tag key used by c.
+func fieldTag(c Codec) string {
+	if t, ok := c.(FieldTagger); ok {
+		return t.FieldTag()
+	}
+	return "json"
+}
+
+// FieldMatcher is implemented by codecs whose decoders match keys to struct
+// fields differently from encoding/json, which ignores case, keys untagged
+// fields by their Go name and flattens untagged embedded structs. Features
+// that map file keys back to struct fields, such as WithStrict, use it to
+// match keys exactly as the decoder does.
+type FieldMatcher interface {
+	// FoldCase reports whether keys match field names regardless of case.
+	FoldCase() bool
+	// DefaultKey returns the key of a field whose tag does not name it.
+	DefaultKey(field string) string
+	// InlineEmbedded reports whether untagged embedded structs are
+	// flattened into their parent. Those tagged ",inline" always are.
+	InlineEmbedded() bool
+}
+
+// jsonFields matches fields the way encoding/json does.
+type jsonFields struct{}
+
+func (jsonFields) FoldCase() bool                 { return true }
+func (jsonFields) DefaultKey(field string) string { return field }
+func (jsonFields) InlineEmbedded() bool           { return true }
+
+// keyMatch maps document keys to struct fields for a codec.
+type keyMatch struct {
+	tag string
+	FieldMatcher
+}
+
+// keyMatchFor returns how c maps keys to fields.
+func keyMatchFor(c Codec) keyMatch {
+	m, ok := c.(FieldMatcher)
+	if !ok {
+		m = jsonFields{}
+	}
+	return keyMatch{tag: fieldTag(c), FieldMatcher: m}
+}
+
+// fold returns key as it is compared with field keys.
+func (k keyMatch) fold(key string) string {
+	if k.FoldCase() {
+		return strings.ToLower(key)
+	}
+	return key
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{".json": jsoncodec.Codec{}}
+)
+
+// RegisterCodec makes c available for detection by file extension. Codecs
+// registered later replace earlier ones for the same extension. Only JSON
+// is registered by default; a file whose extension belongs to another
+// codec subpackage, such as config.yaml, is rejected with
+// ErrCodecNotRegistered until that codec is registered.
+func RegisterCodec(c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	for _, ext := range c.Extensions() {
+		codecs[strings.ToLower(ext)] = c
+	}
+}
+
+// WithCodec sets the codec used to read and write the file, overriding
+// detection by file extension.
+func WithCodec[T any](c Codec) Option[T] {
+	return func(w *Watcher[T]) { w.codec = c }
+}
+
+// modulePath is the import path of this module.
+const modulePath = "github.com/blackorder/configwatcher"
+
+// codecPackages names the codec subpackage for each extension it handles,
+// so that files needing an unregistered codec are not read as JSON.
+var codecPackages = map[string]string{
+	".yaml":  "codec/yaml",
+	".yml":   "codec/yaml",
+	".toml":  "codec/toml",
+	".jsonc": "codec/jsonc",
+}
+
+// codecFor returns the registered codec for filename's extension, falling
+// back to JSON for extensions no codec claims. An extension belonging to a
+// codec subpackage that has not been registered is an error wrapping
+// ErrCodecNotRegistered; the returned codec then fails every call with it.
+func codecFor(filename string) (Codec, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	if c, ok := codecs[ext]; ok {
+		return c, nil
+	}
+	if pkg, ok := codecPackages[ext]; ok {
+		err := fmt.Errorf("%w for %s files; register %s/%s with RegisterCodec or pass it to WithCodec",
+			ErrCodecNotRegistered, ext, modulePath, pkg)
+		return noCodec{err: err}, err
+	}
+	return jsoncodec.Codec{}, nil
+}
+
+// noCodec stands in for a codec that has not been registered.
+type noCodec struct {
+	err error
+}
+
+func (c noCodec) Marshal(any) ([]byte, error) { return nil, c.err }
+func (c noCodec) Unmarshal([]byte, any) error { return c.err }
+func (noCodec) Extensions() []string          { return nil }
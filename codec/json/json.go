@@ -0,0 +1,22 @@
+// Package json provides the JSON Codec used by configwatcher by default.
+package json
+
+import "encoding/json"
+
+// Codec encodes configuration as indented JSON.
+type Codec struct{}
+
+// Marshal encodes v as JSON indented with two spaces.
+func (Codec) Marshal(v any) ([]byte, error) {
+	return json.MarshalIndent(v, "", "  ")
+}
+
+// Unmarshal decodes JSON data into v.
+func (Codec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// Extensions returns the file extensions handled by this codec.
+func (Codec) Extensions() []string {
+	return []string{".json"}
+}
@@ -0,0 +1,27 @@
+package json
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestMarshalIndented(t *testing.T) {
+	data, err := Codec{}.Marshal(map[string]any{"server": map[string]int{"port": 80}})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := "{\n  \"server\": {\n    \"port\": 80\n  }\n}"
+	if string(data) != want {
+		t.Errorf("Expected two-space indentation, got %q", data)
+	}
+}
+
+func TestUnmarshalSyntaxError(t *testing.T) {
+	var v map[string]any
+	err := Codec{}.Unmarshal([]byte(`{"port": 80,}`), &v)
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Offset != 13 {
+		t.Errorf("Expected syntax error at offset 13, got %v", err)
+	}
+}
@@ -0,0 +1,295 @@
+// Package jsonc provides a Codec for JSON with comments and trailing commas
+// (JSONC, as accepted by editors and the HuJSON format). JSON5 additions
+// such as unquoted keys, single-quoted strings and hexadecimal numbers are
+// not supported.
+//
+// Besides decoding annotated files, the codec implements
+// configwatcher.Patcher so that Save only rewrites the values that changed,
+// keeping the comments, key order and formatting written by humans:
+//
+//	configwatcher.RegisterCodec(jsonc.Codec{})
+//	watcher := configwatcher.NewWatcher(cfg, "config.jsonc")
+package jsonc
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/tailscale/hujson"
+
+	"github.com/blackorder/configwatcher/internal/jsonpatch"
+)
+
+// Codec encodes configuration as JSON and decodes JSON with comments and
+// trailing commas.
+type Codec struct{}
+
+// Marshal encodes v as JSON indented with two spaces.
+func (Codec) Marshal(v any) ([]byte, error) {
+	return json.MarshalIndent(v, "", "  ")
+}
+
+// Unmarshal decodes data, which may contain comments and trailing commas,
+// into v.
+func (Codec) Unmarshal(data []byte, v any) error {
+	std, err := standardize(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(std, v)
+}
+
+// Extensions returns the file extensions handled by this codec.
+func (Codec) Extensions() []string {
+	return []string{".jsonc"}
+}
+
+// Patch returns original updated to hold v. Only values that differ are
+// replaced, added or removed; comments and formatting elsewhere in the
+// document are left untouched. Members are matched to struct fields
+// case-insensitively, as json.Unmarshal does, and members that map to no
+// field, such as "$schema", are kept.
+func (Codec) Patch(original []byte, v any) ([]byte, error) {
+	ast, err := hujson.Parse(original)
+	if err != nil {
+		return nil, err
+	}
+	old := ast.Clone()
+	old.Standardize()
+	var oldDoc any
+	if err := json.Unmarshal(old.Pack(), &oldDoc); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var newDoc any
+	if err := json.Unmarshal(data, &newDoc); err != nil {
+		return nil, err
+	}
+	newDoc = align(oldDoc, newDoc, reflect.TypeOf(v))
+
+	ops := jsonpatch.Diff(oldDoc, newDoc)
+	if len(ops) == 0 {
+		return original, nil
+	}
+	patch, err := json.Marshal(ops)
+	if err != nil {
+		return nil, err
+	}
+	if err := ast.Patch(patch); err != nil {
+		return nil, err
+	}
+	indentAdded(&ast, ops)
+	return ast.Pack(), nil
+}
+
+var (
+	jsonMarshaler = reflect.TypeFor[json.Marshaler]()
+	textMarshaler = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// align rewrites doc, the encoding of a value of type t, against the
+// existing document old so that diffing them touches only what changed:
+// struct members take the spelling of the existing key they decode from,
+// and existing members that map to no field are carried over.
+func align(old, doc any, t reflect.Type) any {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || marshalsItself(t) {
+		return doc
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		o, ok1 := old.(map[string]any)
+		d, ok2 := doc.(map[string]any)
+		if !ok1 || !ok2 {
+			return doc
+		}
+		return alignStruct(o, d, fieldTypes(t))
+	case reflect.Map:
+		o, ok1 := old.(map[string]any)
+		d, ok2 := doc.(map[string]any)
+		if !ok1 || !ok2 {
+			return doc
+		}
+		for k, v := range d {
+			if ov, ok := o[k]; ok {
+				d[k] = align(ov, v, t.Elem())
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		o, ok1 := old.([]any)
+		d, ok2 := doc.([]any)
+		if !ok1 || !ok2 || len(o) != len(d) {
+			return doc
+		}
+		for i := range d {
+			d[i] = align(o[i], d[i], t.Elem())
+		}
+	}
+	return doc
+}
+
+// alignStruct is align for an object encoded from a struct with the given
+// fields.
+func alignStruct(old, doc map[string]any, fields map[string]reflect.Type) map[string]any {
+	out := make(map[string]any, len(doc))
+	for k, v := range doc {
+		key := k
+		if _, ok := old[k]; !ok {
+			for ok := range old {
+				if _, field := fields[ok]; !field && strings.EqualFold(ok, k) {
+					key = ok
+					break
+				}
+			}
+		}
+		out[key] = align(old[key], v, fields[k])
+	}
+	for k, v := range old {
+		if _, ok := out[k]; !ok && !mapsToField(fields, k) {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// mapsToField reports whether json.Unmarshal decodes the member key into
+// one of fields.
+func mapsToField(fields map[string]reflect.Type, key string) bool {
+	if _, ok := fields[key]; ok {
+		return true
+	}
+	for name := range fields {
+		if strings.EqualFold(name, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// marshalsItself reports whether values of type t choose their own
+// encoding.
+func marshalsItself(t reflect.Type) bool {
+	for _, m := range []reflect.Type{jsonMarshaler, textMarshaler} {
+		if t.Implements(m) || reflect.PointerTo(t).Implements(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldTypes returns the types of the fields encoding/json encodes for
+// struct type t, keyed by member name, including promoted embedded fields.
+func fieldTypes(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	var embedded []reflect.Type
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	// Fields declared directly win over promoted ones.
+	for _, et := range embedded {
+		for k, v := range fieldTypes(et) {
+			if _, ok := fields[k]; !ok {
+				fields[k] = v
+			}
+		}
+	}
+	return fields
+}
+
+// indentAdded lays out object members inserted by "add" operations like
+// their preceding sibling, so they land on their own line with the same
+// indentation instead of being packed after it.
+func indentAdded(ast *hujson.Value, ops []jsonpatch.Operation) {
+	for _, op := range ops {
+		if op.Op != "add" {
+			continue
+		}
+		i := strings.LastIndexByte(op.Path, '/')
+		parent := ast.Find(op.Path[:i])
+		if parent == nil {
+			continue
+		}
+		obj, ok := parent.Value.(*hujson.Object)
+		if !ok || len(obj.Members) < 2 {
+			continue
+		}
+		name, _ := json.Marshal(jsonpatch.Unescape(op.Path[i+1:]))
+		for j := len(obj.Members) - 1; j > 0; j-- {
+			m := &obj.Members[j]
+			if lit, ok := m.Name.Value.(hujson.Literal); !ok || !bytes.Equal(lit, name) {
+				continue
+			}
+			prev := obj.Members[j-1]
+			m.Name.BeforeExtra = relayout(m.Name.BeforeExtra, indentOf(prev.Name.BeforeExtra))
+			if isSpace(prev.Value.BeforeExtra) {
+				m.Value.BeforeExtra = append(hujson.Extra(nil), prev.Value.BeforeExtra...)
+			}
+			break
+		}
+	}
+}
+
+// indentOf returns the line break and indentation that precede a member
+// with the given leading extra, dropping any comments.
+func indentOf(extra hujson.Extra) hujson.Extra {
+	i := bytes.LastIndexByte(extra, '\n')
+	if i < 0 || !isSpace(extra[i:]) {
+		return hujson.Extra(" ")
+	}
+	return append(hujson.Extra(nil), extra[i:]...)
+}
+
+// relayout replaces the trailing whitespace of extra with indent, keeping
+// any comments hujson carried over from the previous member's line.
+func relayout(extra, indent hujson.Extra) hujson.Extra {
+	if bytes.IndexByte(extra, '\n') >= 0 && indent[0] != '\n' {
+		return extra
+	}
+	trimmed := bytes.TrimRight(extra, " \t\r\n")
+	return append(append(hujson.Extra(nil), trimmed...), indent...)
+}
+
+func isSpace(b []byte) bool {
+	return len(bytes.TrimSpace(b)) == 0
+}
+
+// standardize strips comments and trailing commas without modifying data.
+func standardize(data []byte) ([]byte, error) {
+	ast, err := hujson.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	ast.Standardize()
+	return ast.Pack(), nil
+}
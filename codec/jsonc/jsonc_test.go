@@ -0,0 +1,144 @@
+package jsonc
+
+import (
+	"strings"
+	"testing"
+)
+
+type config struct {
+	Name   string         `json:"name"`
+	Port   int            `json:"port"`
+	Nested map[string]int `json:"nested"`
+}
+
+const annotated = `{
+  // Application name
+  "name": "app",
+  "port": 8080, /* default port */
+  "nested": {
+    "x": 1, // keep me
+  },
+}
+`
+
+func TestUnmarshalComments(t *testing.T) {
+	var cfg config
+	if err := (Codec{}).Unmarshal([]byte(annotated), &cfg); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if cfg.Name != "app" || cfg.Port != 8080 || cfg.Nested["x"] != 1 {
+		t.Errorf("Unexpected config: %+v", cfg)
+	}
+}
+
+func TestUnmarshalRejectsJSON5(t *testing.T) {
+	var cfg config
+	if err := (Codec{}).Unmarshal([]byte(`{name: 'x', port: 0x10,}`), &cfg); err == nil {
+		t.Errorf("Expected JSON5 syntax to be rejected, got %+v", cfg)
+	}
+}
+
+func TestPatchPreservesComments(t *testing.T) {
+	cfg := config{Name: "app", Port: 9090, Nested: map[string]int{"x": 1, "y": 2}}
+
+	out, err := Codec{}.Patch([]byte(annotated), cfg)
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+
+	want := `{
+  // Application name
+  "name": "app",
+  "port": 9090, /* default port */
+  "nested": {
+    "x": 1, // keep me
+    "y": 2
+  },
+}
+`
+	if string(out) != want {
+		t.Errorf("Unexpected patch result:\n%s\nwant:\n%s", out, want)
+	}
+
+	var got config
+	if err := (Codec{}).Unmarshal(out, &got); err != nil {
+		t.Fatalf("Patched document does not decode: %v", err)
+	}
+	if got.Port != 9090 || got.Nested["y"] != 2 {
+		t.Errorf("Unexpected decoded config: %+v", got)
+	}
+}
+
+func TestPatchUnchanged(t *testing.T) {
+	cfg := config{Name: "app", Port: 8080, Nested: map[string]int{"x": 1}}
+
+	out, err := Codec{}.Patch([]byte(annotated), cfg)
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+	if string(out) != annotated {
+		t.Errorf("Unchanged config should leave the document as is, got:\n%s", out)
+	}
+}
+
+func TestPatchRemovesMembers(t *testing.T) {
+	out, err := Codec{}.Patch([]byte(annotated), map[string]any{"name": "app", "port": 8080})
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+	if strings.Contains(string(out), "nested") {
+		t.Errorf("Expected nested to be removed, got:\n%s", out)
+	}
+	if !strings.Contains(string(out), "// Application name") {
+		t.Errorf("Expected comments to be preserved, got:\n%s", out)
+	}
+}
+
+func TestPatchMatchesKeysCaseInsensitively(t *testing.T) {
+	const doc = `{
+  // Application name
+  "Name": "app",
+  "PORT": 8080,
+}
+`
+	out, err := Codec{}.Patch([]byte(doc), config{Name: "other", Port: 8080})
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+	want := `{
+  // Application name
+  "Name": "other",
+  "PORT": 8080,
+  "nested": null
+}
+`
+	if string(out) != want {
+		t.Errorf("Unexpected patch result:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestPatchKeepsUnknownMembers(t *testing.T) {
+	const doc = `{
+  "$schema": "./config.schema.json",
+  "name": "app",
+  "port": 8080, // default port
+  "nested": {"x": 1},
+  "note": "ask ops before changing the port",
+}
+`
+	out, err := Codec{}.Patch([]byte(doc), config{Name: "app", Port: 9090, Nested: map[string]int{}})
+	if err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+	want := `{
+  "$schema": "./config.schema.json",
+  "name": "app",
+  "port": 9090, // default port
+  "nested": {},
+  "note": "ask ops before changing the port",
+}
+`
+	if string(out) != want {
+		t.Errorf("Unexpected patch result:\n%s\nwant:\n%s", out, want)
+	}
+}
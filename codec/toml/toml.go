@@ -0,0 +1,35 @@
+// Package toml provides a TOML Codec for configwatcher.
+//
+// Register it for extension-based detection or pass it explicitly:
+//
+//	configwatcher.RegisterCodec(toml.Codec{})
+//	watcher := configwatcher.NewWatcher(cfg, "config.toml",
+//		configwatcher.WithCodec[Config](toml.Codec{}))
+package toml
+
+import (
+	gotoml "github.com/BurntSushi/toml"
+)
+
+// Codec encodes configuration as TOML.
+type Codec struct{}
+
+// Marshal encodes v as TOML.
+func (Codec) Marshal(v any) ([]byte, error) {
+	return gotoml.Marshal(v)
+}
+
+// Unmarshal decodes TOML data into v.
+func (Codec) Unmarshal(data []byte, v any) error {
+	return gotoml.Unmarshal(data, v)
+}
+
+// FieldTag returns the struct tag key used to name fields in TOML.
+func (Codec) FieldTag() string {
+	return "toml"
+}
+
+// Extensions returns the file extensions handled by this codec.
+func (Codec) Extensions() []string {
+	return []string{".toml"}
+}
@@ -0,0 +1,39 @@
+package toml
+
+import (
+	"errors"
+	"testing"
+
+	gotoml "github.com/BurntSushi/toml"
+)
+
+type server struct {
+	Port int `toml:"listen_port" json:"port"`
+}
+
+type config struct {
+	Name   string `toml:"name"`
+	Server server `toml:"server"`
+}
+
+func TestTOMLTables(t *testing.T) {
+	data, err := Codec{}.Marshal(config{Name: "app", Server: server{Port: 80}})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if want := "name = \"app\"\n\n[server]\n  listen_port = 80\n"; string(data) != want {
+		t.Errorf("Expected nested struct as a table keyed by toml tags, got %q", data)
+	}
+	if (Codec{}).FieldTag() != "toml" {
+		t.Error(`Expected field tag "toml"`)
+	}
+}
+
+func TestUnmarshalError(t *testing.T) {
+	var got config
+	err := Codec{}.Unmarshal([]byte("name = \"app\"\n[server]\nlisten_port = \n"), &got)
+	var parseErr gotoml.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Position.Line != 3 {
+		t.Errorf("Expected parse error on line 3, got %v", err)
+	}
+}
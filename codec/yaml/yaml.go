@@ -0,0 +1,63 @@
+// Package yaml provides a YAML Codec for configwatcher.
+//
+// Register it for extension-based detection or pass it explicitly:
+//
+//	configwatcher.RegisterCodec(yaml.Codec{})
+//	watcher := configwatcher.NewWatcher(cfg, "config.yaml",
+//		configwatcher.WithCodec[Config](yaml.Codec{}))
+package yaml
+
+import (
+	"bytes"
+	"strings"
+
+	goyaml "gopkg.in/yaml.v3"
+)
+
+// Codec encodes configuration as YAML.
+type Codec struct{}
+
+// Marshal encodes v as YAML indented with two spaces.
+func (Codec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := goyaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes YAML data into v.
+func (Codec) Unmarshal(data []byte, v any) error {
+	return goyaml.Unmarshal(data, v)
+}
+
+// FieldTag returns the struct tag key used to name fields in YAML.
+func (Codec) FieldTag() string {
+	return "yaml"
+}
+
+// FoldCase reports false: YAML keys match field names case-sensitively.
+func (Codec) FoldCase() bool {
+	return false
+}
+
+// DefaultKey returns the key of an untagged field, its lowercased name.
+func (Codec) DefaultKey(field string) string {
+	return strings.ToLower(field)
+}
+
+// InlineEmbedded reports false: YAML only flattens embedded structs tagged
+// ",inline".
+func (Codec) InlineEmbedded() bool {
+	return false
+}
+
+// Extensions returns the file extensions handled by this codec.
+func (Codec) Extensions() []string {
+	return []string{".yaml", ".yml"}
+}
@@ -0,0 +1,40 @@
+package yaml
+
+import (
+	"strings"
+	"testing"
+)
+
+type config struct {
+	ListenPort int `yaml:"listen_port" json:"port"`
+	Name       string
+}
+
+func TestYAMLTags(t *testing.T) {
+	data, err := Codec{}.Marshal(config{ListenPort: 80, Name: "app"})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if want := "listen_port: 80\nname: app\n"; string(data) != want {
+		t.Errorf("Expected keys from yaml tags and lowercased names, got %q", data)
+	}
+
+	var got config
+	if err := (Codec{}).Unmarshal([]byte("listen_port: 443\nName: ignored\n"), &got); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if got.ListenPort != 443 || got.Name != "" {
+		t.Errorf("Expected case-sensitive key matching, got %+v", got)
+	}
+	if c := (Codec{}); c.FieldTag() != "yaml" || c.FoldCase() || c.InlineEmbedded() || c.DefaultKey("Name") != "name" {
+		t.Error("Field matching does not describe the YAML decoder")
+	}
+}
+
+func TestUnmarshalError(t *testing.T) {
+	var got config
+	err := Codec{}.Unmarshal([]byte("listen_port: [80\nname: app\n"), &got)
+	if err == nil || !strings.Contains(err.Error(), "line") {
+		t.Errorf("Expected a decode error with its line, got %v", err)
+	}
+}
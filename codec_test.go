@@ -0,0 +1,169 @@
+package configwatcher
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	jsoncodec "github.com/blackorder/configwatcher/codec/json"
+	jsonccodec "github.com/blackorder/configwatcher/codec/jsonc"
+	tomlcodec "github.com/blackorder/configwatcher/codec/toml"
+	yamlcodec "github.com/blackorder/configwatcher/codec/yaml"
+)
+
+type codecConfig struct {
+	Name string `json:"name" yaml:"name" toml:"name"`
+	Port int    `json:"port" yaml:"port" toml:"port"`
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	type config struct {
+		Name  string   `json:"name" yaml:"name" toml:"name"`
+		Port  int      `json:"port" yaml:"port" toml:"port"`
+		Hosts []string `json:"hosts" yaml:"hosts" toml:"hosts"`
+	}
+	want := config{Name: "app", Port: 8080, Hosts: []string{"a", "b"}}
+
+	for _, c := range []Codec{jsoncodec.Codec{}, jsonccodec.Codec{}, yamlcodec.Codec{}, tomlcodec.Codec{}} {
+		t.Run(c.Extensions()[0], func(t *testing.T) {
+			data, err := c.Marshal(want)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			var got config
+			if err := c.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Round trip mismatch: got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestCodecForExtension(t *testing.T) {
+	RegisterCodec(yamlcodec.Codec{})
+	RegisterCodec(tomlcodec.Codec{})
+
+	tests := []struct {
+		filename string
+		want     Codec
+	}{
+		{"config.json", jsoncodec.Codec{}},
+		{"config.yaml", yamlcodec.Codec{}},
+		{"config.YML", yamlcodec.Codec{}},
+		{"config.toml", tomlcodec.Codec{}},
+		{"config", jsoncodec.Codec{}},
+		{"config.conf", jsoncodec.Codec{}},
+	}
+	for _, tt := range tests {
+		if got, err := codecFor(tt.filename); err != nil || got != tt.want {
+			t.Errorf("codecFor(%q) = %T, %v; want %T", tt.filename, got, err, tt.want)
+		}
+	}
+}
+
+func TestUnregisteredCodec(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.jsonc")
+	if err := os.WriteFile(configFile, []byte(`{"name": "x"}`), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	_, err := New(codecConfig{}, configFile)
+	if !errors.Is(err, ErrCodecNotRegistered) || !strings.Contains(err.Error(), "codec/jsonc") {
+		t.Errorf("Expected ErrCodecNotRegistered naming the codec package, got %v", err)
+	}
+
+	errChan := make(chan error, 10)
+	watcher := NewWatcher(codecConfig{Name: "default"}, configFile, WithErrorChan[codecConfig](errChan))
+	defer watcher.Close()
+	if err := <-errChan; !errors.Is(err, ErrCodecNotRegistered) {
+		t.Errorf("Expected ErrCodecNotRegistered on the error channel, got %v", err)
+	}
+	if err := watcher.Save(codecConfig{Name: "saved"}); !errors.Is(err, ErrCodecNotRegistered) {
+		t.Errorf("Expected Save to fail with ErrCodecNotRegistered, got %v", err)
+	}
+	if data, _ := os.ReadFile(configFile); string(data) != `{"name": "x"}` {
+		t.Errorf("Expected file to be left alone, got %s", data)
+	}
+}
+
+func TestWatcherYAMLCodec(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFile, []byte("name: yaml\nport: 8080\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	watcher, err := New(codecConfig{}, configFile, WithCodec[codecConfig](yamlcodec.Codec{}))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	if got := watcher.Get(); got.Name != "yaml" || got.Port != 8080 {
+		t.Errorf("Expected YAML config, got %+v", got)
+	}
+
+	if err := watcher.Save(codecConfig{Name: "saved", Port: 9090}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		t.Fatalf("Failed to read config file: %v", err)
+	}
+	if !strings.Contains(string(data), "port: 9090") {
+		t.Errorf("Expected YAML output, got %q", data)
+	}
+}
+
+func TestWatcherTOMLCodecDetected(t *testing.T) {
+	RegisterCodec(tomlcodec.Codec{})
+
+	configFile := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(configFile, []byte("name = \"toml\"\nport = 7070\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	watcher, err := New(codecConfig{}, configFile)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	if got := watcher.Get(); got.Name != "toml" || got.Port != 7070 {
+		t.Errorf("Expected TOML config, got %+v", got)
+	}
+}
+
+func TestWatcherJSONCSavePreservesComments(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.jsonc")
+	annotated := "{\n  // service name\n  \"name\": \"app\",\n  \"port\": 8080, // default\n}\n"
+	if err := os.WriteFile(configFile, []byte(annotated), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	watcher, err := New(codecConfig{}, configFile, WithCodec[codecConfig](jsonccodec.Codec{}))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	if got := watcher.Get(); got.Name != "app" || got.Port != 8080 {
+		t.Fatalf("Expected annotated config to load, got %+v", got)
+	}
+
+	if err := watcher.Save(codecConfig{Name: "app", Port: 9090}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		t.Fatalf("Failed to read config file: %v", err)
+	}
+	want := strings.Replace(annotated, "8080", "9090", 1)
+	if string(data) != want {
+		t.Errorf("Expected comments to survive Save, got:\n%s", data)
+	}
+}
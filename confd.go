@@ -0,0 +1,91 @@
+package configwatcher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WithDirectory makes the watcher treat its path as a conf.d-style
+// directory. Every file in it whose name matches the glob pattern, such as
+// "*.json", is a fragment; fragments are deep-merged over the default value
+// in lexical order, each decoded with the codec for its extension, and
+// adding, changing or removing one reloads the configuration. Hidden files
+// are ignored. A fragment that cannot be read or decoded is reported as a
+// *FragmentError while the others still apply, using its last good content
+// if it had any. The directory is never written to: Save returns
+// ErrReadOnly.
+func WithDirectory[T any](pattern string) Option[T] {
+	return func(w *Watcher[T]) {
+		if pattern == "" {
+			pattern = "*"
+		}
+		w.dirPattern = pattern
+		w.readOnly = true
+	}
+}
+
+// isFragment reports whether name is a fragment file in directory mode.
+func (w *Watcher[T]) isFragment(name string) bool {
+	if w.dirPattern == "" || filepath.Dir(name) != w.filename {
+		return false
+	}
+	base := filepath.Base(name)
+	ok, _ := filepath.Match(w.dirPattern, base)
+	return ok && !strings.HasPrefix(base, ".")
+}
+
+// fragmentFiles lists the fragments in lexical order.
+func (w *Watcher[T]) fragmentFiles() ([]string, error) {
+	entries, err := os.ReadDir(w.filename)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		name := filepath.Join(w.filename, e.Name())
+		if !e.IsDir() && w.isFragment(name) {
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
+
+// mergeFragments deep-merges every fragment over merged. Fragments that
+// fail are reported and fall back to their last good content, as does an
+// empty fragment, which is assumed to be mid-write. Callers must hold w.mu.
+func (w *Watcher[T]) mergeFragments(merged map[string]any, prov map[string]string) map[string]any {
+	files, err := w.fragmentFiles()
+	if err != nil {
+		w.sendError(err)
+		return merged
+	}
+	good := make(map[string][]byte, len(files))
+	for _, f := range files {
+		c, err := codecFor(f)
+		var data []byte
+		if err == nil {
+			data, err = os.ReadFile(f)
+		}
+		var doc map[string]any
+		if err == nil && len(data) > 0 {
+			doc, err = decodeDoc(c, data)
+			if err == nil {
+				err = w.checkKeys(f, c, doc)
+			}
+		}
+		if err != nil {
+			w.sendError(&FragmentError{Path: f, Err: err})
+		}
+		if err != nil || len(data) == 0 {
+			if data = w.fragments[f]; data == nil {
+				continue
+			}
+			doc, _ = decodeDoc(c, data)
+		}
+		good[f] = data
+		merged, _ = w.merge(merged, doc, "", f, prov).(map[string]any)
+	}
+	w.fragments = good
+	return merged
+}
@@ -0,0 +1,112 @@
+package configwatcher
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newConfDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeJSON(t, filepath.Join(dir, "10-base.json"), map[string]any{"name": "base", "count": 1})
+	writeJSON(t, filepath.Join(dir, "20-override.json"), map[string]any{"count": 2})
+	writeJSON(t, filepath.Join(dir, ".hidden.json"), map[string]any{"count": 99})
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("not a fragment"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	return dir
+}
+
+func TestDirectoryMerge(t *testing.T) {
+	dir := newConfDir(t)
+	errChan := make(chan error, 10)
+	watcher, err := New(TestConfig{Settings: map[string]string{"from": "default"}}, dir,
+		WithDirectory[TestConfig]("*.json"), WithErrorChan[TestConfig](errChan))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	got := watcher.Get()
+	if got.Name != "base" || got.Count != 2 || got.Settings["from"] != "default" {
+		t.Errorf("Expected fragments merged over defaults, got %+v", got)
+	}
+	if src, _ := watcher.Source("count"); src != filepath.Join(dir, "20-override.json") {
+		t.Errorf("Expected count from 20-override.json, got %q", src)
+	}
+	if err := watcher.Save(got); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := watcher.SubscribeChanges(ctx)
+
+	writeJSON(t, filepath.Join(dir, "30-added.json"), map[string]any{"name": "added"})
+	if c := receiveChange(t, changes); c.New.Name != "added" {
+		t.Errorf("Expected added fragment to apply, got %+v", c.New)
+	}
+
+	os.Remove(filepath.Join(dir, "20-override.json"))
+	if c := receiveChange(t, changes); c.New.Count != 1 {
+		t.Errorf("Expected removed fragment to stop applying, got %+v", c.New)
+	}
+}
+
+func TestDirectoryBrokenFragment(t *testing.T) {
+	dir := newConfDir(t)
+	errChan := make(chan error, 10)
+	watcher, err := New(TestConfig{}, dir,
+		WithDirectory[TestConfig]("*.json"), WithErrorChan[TestConfig](errChan))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := watcher.SubscribeChanges(ctx)
+
+	broken := filepath.Join(dir, "20-override.json")
+	if err := os.WriteFile(broken, []byte("{not json"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	var fragErr *FragmentError
+	select {
+	case err := <-errChan:
+		if !errors.As(err, &fragErr) || fragErr.Path != broken {
+			t.Errorf("Expected *FragmentError for %s, got %v", broken, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected broken fragment to be reported")
+	}
+
+	writeJSON(t, filepath.Join(dir, "10-base.json"), map[string]any{"name": "changed", "count": 1})
+	c := receiveChange(t, changes)
+	if c.New.Name != "changed" || c.New.Count != 2 {
+		t.Errorf("Expected other fragments to apply and the broken one to keep its last good value, got %+v", c.New)
+	}
+}
+
+func TestDirectoryPolling(t *testing.T) {
+	dir := newConfDir(t)
+	watcher, err := New(TestConfig{}, dir,
+		WithDirectory[TestConfig]("*.json"), WithPolling[TestConfig](20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := watcher.SubscribeChanges(ctx)
+
+	writeJSON(t, filepath.Join(dir, "30-added.json"), map[string]any{"count": 3})
+	if c := receiveChange(t, changes); c.New.Count != 3 {
+		t.Errorf("Expected polled fragment to apply, got %+v", c.New)
+	}
+}
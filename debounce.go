@@ -0,0 +1,76 @@
+package configwatcher
+
+import "time"
+
+// defaultMaxWaitFactor bounds how long WithDebounce may postpone a reload
+// when WithMaxWait is not set, as a multiple of the quiet window.
+const defaultMaxWaitFactor = 10
+
+// WithDebounce coalesces bursts of file events into a single reload that
+// happens once no event has arrived for the quiet duration. Editors and
+// copy tools often emit several events per save; without debouncing each
+// one re-reads and re-parses the file.
+func WithDebounce[T any](quiet time.Duration) Option[T] {
+	return func(w *Watcher[T]) { w.debounce.quiet = quiet }
+}
+
+// WithMaxWait bounds how long WithDebounce may postpone a reload, so a file
+// that is touched continuously is still reloaded periodically. It defaults
+// to ten times the quiet duration.
+func WithMaxWait[T any](maxWait time.Duration) Option[T] {
+	return func(w *Watcher[T]) { w.debounce.maxWait = maxWait }
+}
+
+// debouncer schedules a single reload after a burst of events: quiet after
+// the last event, but no later than maxWait after the first one.
+type debouncer struct {
+	quiet   time.Duration
+	maxWait time.Duration
+	timer   *time.Timer
+	first   time.Time
+	pending bool
+}
+
+// enabled reports whether events should be debounced at all.
+func (d *debouncer) enabled() bool {
+	return d.quiet > 0
+}
+
+// touch records an event at now and reschedules the reload.
+func (d *debouncer) touch(now time.Time) {
+	if !d.pending {
+		d.pending = true
+		d.first = now
+	}
+	maxWait := d.maxWait
+	if maxWait <= 0 {
+		maxWait = defaultMaxWaitFactor * d.quiet
+	}
+	wait := min(d.quiet, d.first.Add(maxWait).Sub(now))
+	if d.timer == nil {
+		d.timer = time.NewTimer(wait)
+	} else {
+		d.timer.Reset(wait)
+	}
+}
+
+// C returns the channel that fires when the scheduled reload is due, or
+// nil when none is pending.
+func (d *debouncer) C() <-chan time.Time {
+	if !d.pending {
+		return nil
+	}
+	return d.timer.C
+}
+
+// fired marks the scheduled reload as done.
+func (d *debouncer) fired() {
+	d.pending = false
+}
+
+// stop releases the timer.
+func (d *debouncer) stop() {
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+}
@@ -0,0 +1,68 @@
+package configwatcher
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDebouncerSchedule(t *testing.T) {
+	d := debouncer{quiet: 50 * time.Millisecond, maxWait: 120 * time.Millisecond}
+	defer d.stop()
+
+	if d.C() != nil {
+		t.Fatal("Expected no pending reload")
+	}
+
+	start := time.Now()
+	d.touch(start)
+	for i := 0; i < 10; i++ {
+		time.Sleep(20 * time.Millisecond)
+		if fired := func() bool {
+			select {
+			case <-d.C():
+				return true
+			default:
+				return false
+			}
+		}(); fired {
+			d.fired()
+			break
+		}
+		d.touch(time.Now())
+	}
+	if d.pending {
+		t.Fatal("Expected max wait to force a reload during a continuous burst")
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("Reload postponed for %v, beyond max wait", elapsed)
+	}
+}
+
+func TestWithDebounceCoalescesEvents(t *testing.T) {
+	configFile := createTempConfigFile(t, TestConfig{Name: "initial"})
+	watcher, err := New(TestConfig{}, configFile, WithDebounce[TestConfig](150*time.Millisecond))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := watcher.SubscribeChanges(ctx)
+
+	for i := 1; i <= 5; i++ {
+		writeJSON(t, configFile, TestConfig{Name: "burst", Count: i})
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	c := receiveChange(t, changes)
+	if c.New.Count != 5 {
+		t.Errorf("Expected a single reload with the final value, got %+v", c.New)
+	}
+	select {
+	case c := <-changes:
+		t.Errorf("Expected burst to be coalesced, got extra change %+v", c)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
@@ -0,0 +1,99 @@
+package configwatcher
+
+import (
+	"reflect"
+)
+
+// WithWriteDefaults makes the watcher add settings that are missing from
+// the file, such as fields newly added to T, with their default values, so
+// operators can see and edit them. The file is rewritten only when keys
+// are missing; codecs implementing Patcher keep the rest of the document
+// as it was. It has no effect under WithReadOnly, WithLayers or
+// WithDirectory.
+func WithWriteDefaults[T any]() Option[T] {
+	return func(w *Watcher[T]) { w.writeDefaults = true }
+}
+
+// applyTagDefaults fills the default value from `default` struct tags. It
+// runs before the initial load.
+func (w *Watcher[T]) applyTagDefaults() error {
+	def, err := w.tagDefaults(w.defaultVal)
+	if err != nil {
+		return err
+	}
+	w.defaultVal, w.fileVal = def, def
+	w.value.Store(def)
+	return nil
+}
+
+// tagDefaults returns a copy of v with every zero field that has a
+// `default:"..."` tag set from the tag.
+func (w *Watcher[T]) tagDefaults(v T) (T, error) {
+	rv := reflect.ValueOf(&v).Elem()
+	fields := fieldsOf(rv.Type())
+	tagged := false
+	for _, f := range fields {
+		if _, ok := f.sf.Tag.Lookup("default"); ok {
+			tagged = true
+			break
+		}
+	}
+	if !tagged {
+		return v, nil
+	}
+	v, err := w.clone(v)
+	if err != nil {
+		return v, err
+	}
+	rv = reflect.ValueOf(&v).Elem()
+	for _, f := range fields {
+		def, ok := f.sf.Tag.Lookup("default")
+		if !ok {
+			continue
+		}
+		if cur, ok := fieldByIndex(rv, f.index, false); ok && !cur.IsZero() {
+			continue
+		}
+		fv, _ := fieldByIndex(rv, f.index, true)
+		if err := setFromString(fv, def); err != nil {
+			return v, &OverrideError{Source: "default", Name: f.path, Err: err}
+		}
+	}
+	return v, nil
+}
+
+// addMissingKeys rewrites the file with fileVal, the value decoded from
+// data, if data lacks any of its keys. Callers must hold w.mu.
+func (w *Watcher[T]) addMissingKeys(data []byte, fileVal T) error {
+	if w.readOnly || w.merging() {
+		return nil
+	}
+	have, err := decodeDoc(w.codec, data)
+	if err != nil {
+		return err
+	}
+	want, err := w.roundTrip(fileVal)
+	if err != nil {
+		return err
+	}
+	if !missingKeys(have, want) {
+		return nil
+	}
+	return w.withFileLock(func() error { return w.writeFile(fileVal) })
+}
+
+// missingKeys reports whether want has keys, at any depth, that have lacks.
+func missingKeys(have, want map[string]any) bool {
+	for k, wv := range want {
+		hv, ok := have[k]
+		if !ok {
+			return true
+		}
+		hm, ok1 := hv.(map[string]any)
+		wm, ok2 := wv.(map[string]any)
+		if ok1 && ok2 && missingKeys(hm, wm) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package configwatcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type defaultsConfig struct {
+	Name    string        `json:"name"`
+	Port    int           `json:"port" default:"8080"`
+	Timeout time.Duration `json:"timeout" default:"5s"`
+	Nested  struct {
+		Level string `json:"level" default:"info"`
+		Limit int    `json:"limit"`
+	} `json:"nested"`
+}
+
+func writeRaw(t *testing.T, filename, data string) {
+	t.Helper()
+	if err := os.WriteFile(filename, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+func TestMissingFieldsKeepDefaults(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	writeRaw(t, configFile, `{"name": "file"}`)
+
+	watcher, err := New(TestConfig{Name: "default", Count: 5}, configFile)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+	if got := watcher.Get(); got.Name != "file" || got.Count != 5 {
+		t.Errorf("Expected missing count to keep its default, got %+v", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := watcher.SubscribeChanges(ctx)
+	writeRaw(t, configFile, `{"count": 7}`)
+	if c := receiveChange(t, changes); c.New.Name != "default" || c.New.Count != 7 {
+		t.Errorf("Expected removed name to fall back to its default, got %+v", c.New)
+	}
+}
+
+func TestDefaultTags(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	writeRaw(t, configFile, `{"name": "file"}`)
+
+	var def defaultsConfig
+	def.Port = 9000 // an explicit default wins over the tag
+	watcher, err := New(def, configFile)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	got := watcher.Get()
+	if got.Name != "file" || got.Port != 9000 || got.Timeout != 5*time.Second || got.Nested.Level != "info" {
+		t.Errorf("Expected tag defaults to fill zero fields, got %+v", got)
+	}
+}
+
+func TestInvalidDefaultTag(t *testing.T) {
+	type badConfig struct {
+		Port int `json:"port" default:"eighty"`
+	}
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	writeRaw(t, configFile, `{}`)
+
+	_, err := New(badConfig{}, configFile)
+	var overrideErr *OverrideError
+	if !errors.As(err, &overrideErr) || overrideErr.Source != "default" {
+		t.Errorf("Expected *OverrideError for the default tag, got %v", err)
+	}
+}
+
+func TestWithWriteDefaults(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	writeRaw(t, configFile, `{"name": "file", "nested": {"limit": 3}}`)
+
+	watcher, err := New(defaultsConfig{}, configFile, WithWriteDefaults[defaultsConfig]())
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	var onDisk map[string]any
+	data, _ := os.ReadFile(configFile)
+	if err := json.Unmarshal(data, &onDisk); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	nested, _ := onDisk["nested"].(map[string]any)
+	if onDisk["name"] != "file" || onDisk["port"] != float64(8080) || nested["level"] != "info" || nested["limit"] != float64(3) {
+		t.Errorf("Expected missing defaults to be written back, got %s", data)
+	}
+
+	info, _ := os.Stat(configFile)
+	if err := watcher.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if after, _ := os.Stat(configFile); !after.ModTime().Equal(info.ModTime()) {
+		t.Error("File without missing keys should not be rewritten")
+	}
+}
+
+func TestWithWriteDefaultsReadOnly(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	writeRaw(t, configFile, `{"name": "file"}`)
+
+	watcher, err := New(defaultsConfig{}, configFile,
+		WithWriteDefaults[defaultsConfig](), WithReadOnly[defaultsConfig]())
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+	if data, _ := os.ReadFile(configFile); string(data) != `{"name": "file"}` {
+		t.Errorf("Read-only file must not be rewritten, got %s", data)
+	}
+}
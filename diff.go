@@ -0,0 +1,124 @@
+package configwatcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/blackorder/configwatcher/internal/jsonpatch"
+)
+
+// FieldChange describes a single value that differs between two
+// configurations, addressed by the keys of their JSON encoding.
+type FieldChange struct {
+	// Op is the RFC 6902 operation: "add", "remove" or "replace".
+	Op string
+	// Path is the dotted path of the value, such as "server.port" or
+	// "hosts[1]".
+	Path string
+	// Pointer is the RFC 6901 JSON Pointer of the value, such as
+	// "/server/port".
+	Pointer string
+	// Old and New hold the decoded JSON values; numbers are json.Number.
+	// Old is nil for "add" and New is nil for "remove".
+	Old any
+	New any
+}
+
+// Delta lists the differences between two configurations.
+type Delta []FieldChange
+
+// Paths returns the dotted path of every changed value.
+func (d Delta) Paths() []string {
+	paths := make([]string, len(d))
+	for i, c := range d {
+		paths[i] = c.Path
+	}
+	return paths
+}
+
+// JSONPatch encodes d as an RFC 6902 JSON Patch document.
+func (d Delta) JSONPatch() ([]byte, error) {
+	ops := make([]jsonpatch.Operation, len(d))
+	for i, c := range d {
+		ops[i] = jsonpatch.Operation{Op: c.Op, Path: c.Pointer, Value: c.New}
+	}
+	return json.Marshal(ops)
+}
+
+// Diff returns the values that differ between a and b, compared through
+// their JSON encoding. Objects are compared key by key and arrays element
+// by element when their lengths match; otherwise the whole value is
+// reported as replaced.
+func Diff[T any](a, b T) (Delta, error) {
+	ad, err := toJSONValue(a)
+	if err != nil {
+		return nil, err
+	}
+	bd, err := toJSONValue(b)
+	if err != nil {
+		return nil, err
+	}
+	ops := jsonpatch.Diff(ad, bd)
+	if len(ops) == 0 {
+		return nil, nil
+	}
+	delta := make(Delta, len(ops))
+	for i, op := range ops {
+		doc := bd
+		if op.Op == "remove" {
+			doc = ad
+		}
+		delta[i] = FieldChange{
+			Op:      op.Op,
+			Path:    dottedPath(doc, op.Path),
+			Pointer: op.Path,
+			Old:     op.Old,
+			New:     op.Value,
+		}
+	}
+	return delta, nil
+}
+
+// toJSONValue encodes v as JSON and decodes it into generic values.
+func toJSONValue(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var out any
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// dottedPath converts a JSON Pointer into doc into dotted form, writing
+// array indexes in brackets.
+func dottedPath(doc any, pointer string) string {
+	if pointer == "" {
+		return ""
+	}
+	var b strings.Builder
+	cur := doc
+	for _, tok := range strings.Split(pointer[1:], "/") {
+		tok = jsonpatch.Unescape(tok)
+		switch v := cur.(type) {
+		case []any:
+			b.WriteString("[" + tok + "]")
+			if i, err := strconv.Atoi(tok); err == nil && i < len(v) {
+				cur = v[i]
+			}
+		case map[string]any:
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(tok)
+			cur = v[tok]
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,114 @@
+package configwatcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type diffServer struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+}
+
+type diffConfig struct {
+	Server diffServer        `json:"server"`
+	Hosts  []string          `json:"hosts"`
+	Limits map[string]int    `json:"limits,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+func TestDiff(t *testing.T) {
+	a := diffConfig{
+		Server: diffServer{Host: "localhost", Port: 8080},
+		Hosts:  []string{"a", "b"},
+		Limits: map[string]int{"404": 1},
+		Labels: map[string]string{"env": "dev"},
+	}
+	b := diffConfig{
+		Server: diffServer{Host: "localhost", Port: 9090},
+		Hosts:  []string{"a", "c"},
+		Limits: map[string]int{"404": 2},
+	}
+
+	delta, err := Diff(a, b)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	want := Delta{
+		{Op: "remove", Path: "labels", Pointer: "/labels", Old: map[string]any{"env": "dev"}},
+		{Op: "replace", Path: "hosts[1]", Pointer: "/hosts/1", Old: "b", New: "c"},
+		{Op: "replace", Path: "limits.404", Pointer: "/limits/404", Old: json.Number("1"), New: json.Number("2")},
+		{Op: "replace", Path: "server.port", Pointer: "/server/port", Old: json.Number("8080"), New: json.Number("9090")},
+	}
+	if !reflect.DeepEqual(delta, want) {
+		t.Errorf("Diff mismatch:\ngot  %+v\nwant %+v", delta, want)
+	}
+
+	if delta, err := Diff(a, a); err != nil || delta != nil {
+		t.Errorf("Expected no differences, got %+v, %v", delta, err)
+	}
+}
+
+func TestDeltaJSONPatch(t *testing.T) {
+	a := diffConfig{Server: diffServer{Port: 1}, Labels: map[string]string{"x": "1"}}
+	b := diffConfig{Server: diffServer{Port: 2}, Limits: map[string]int{"max": 3}}
+
+	delta, err := Diff(a, b)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	patch, err := delta.JSONPatch()
+	if err != nil {
+		t.Fatalf("JSONPatch failed: %v", err)
+	}
+	want := `[{"op":"remove","path":"/labels"},` +
+		`{"op":"add","path":"/limits","value":{"max":3}},` +
+		`{"op":"replace","path":"/server/port","value":2}]`
+	if string(patch) != want {
+		t.Errorf("Got %s, want %s", patch, want)
+	}
+}
+
+func TestChangeCarriesDelta(t *testing.T) {
+	configFile := createTempConfigFile(t, TestConfig{Name: "before", Count: 1})
+	watcher, err := New(TestConfig{}, configFile)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := watcher.SubscribeChanges(ctx)
+
+	if err := watcher.Save(TestConfig{Name: "after", Count: 1}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	c := receiveChange(t, changes)
+	if paths := c.Delta.Paths(); !reflect.DeepEqual(paths, []string{"name"}) {
+		t.Errorf("Expected only name to change, got %v", paths)
+	}
+}
+
+func ExampleDiff() {
+	type Config struct {
+		Port  int    `json:"port"`
+		Debug bool   `json:"debug"`
+		DSN   string `json:"dsn"`
+	}
+
+	delta, _ := Diff(
+		Config{Port: 8080, DSN: "postgres://old"},
+		Config{Port: 9090, DSN: "postgres://new"},
+	)
+	for _, c := range delta {
+		fmt.Printf("%s: %v -> %v\n", c.Path, c.Old, c.New)
+	}
+
+	// Output:
+	// dsn: postgres://old -> postgres://new
+	// port: 8080 -> 9090
+}
@@ -2,9 +2,9 @@
 Package configwatcher provides type-safe configuration file watching with automatic reloading.
 
 ConfigWatcher is designed to monitor configuration files for changes and automatically
-reload them while providing type-safe access through Go generics. It supports JSON
-configuration files and offers broadcasting capabilities for configuration change
-notifications.
+reload them while providing type-safe access through Go generics. It supports JSON,
+YAML and TOML configuration files through pluggable codecs and offers
+broadcasting capabilities for configuration change notifications.
 
 # Basic Usage
 
@@ -25,6 +25,29 @@ Create a configuration type and use NewWatcher to start monitoring a file:
 	watcher := configwatcher.NewWatcher(defaultConfig, "config.json")
 	config := watcher.Get()
 
+Use New instead of NewWatcher to fail fast when the initial configuration
+cannot be loaded. WithRequireFile additionally turns a missing or empty file
+into an error instead of creating it from the defaults:
+
+	watcher, err := configwatcher.New(defaultConfig, "config.json",
+		configwatcher.WithRequireFile[Config]())
+	if err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+
+Decode failures are returned as *ParseError and I/O failures as
+*fs.PathError.
+
+Settings missing from the file keep their default values: every load
+decodes the file on top of a copy of the default value. Zero fields of the
+default value can also be set with `default:"..."` struct tags, and
+WithWriteDefaults adds missing keys to the file so operators can see them:
+
+	type Config struct {
+		Port    int           `json:"port" default:"8080"`
+		Timeout time.Duration `json:"timeout" default:"5s"`
+	}
+
 # Configuration Changes
 
 Subscribe to configuration changes using the Subscribe method:
@@ -39,6 +62,40 @@ Subscribe to configuration changes using the Subscribe method:
 		}
 	}()
 
+To know what changed, use SubscribeChanges. Each Change carries the old and
+new values, a monotonically increasing revision, a timestamp and its cause.
+Changes are delivered in order and never dropped:
+
+	for c := range watcher.SubscribeChanges(ctx) {
+		if c.Old.DSN != c.New.DSN {
+			reconnect(c.New.DSN)
+		}
+	}
+
+Change.Delta lists the values that changed as dotted JSON paths with their
+old and new values, and can be rendered as an RFC 6902 JSON Patch. The same
+comparison is available as the standalone Diff function:
+
+	delta, err := configwatcher.Diff(oldConfig, newConfig)
+	for _, fc := range delta {
+		log.Printf("%s: %v -> %v", fc.Path, fc.Old, fc.New)
+	}
+
+Components that care about part of the configuration can subscribe to a
+path, or to a derived value with Select. Both only fire when that part
+actually changes:
+
+	ports := watcher.SubscribePath(ctx, "server.port")
+
+	server := configwatcher.Select(watcher, func(c Config) ServerConfig {
+		return c.Server
+	})
+	for range server.Subscribe(ctx) {
+		restart(server.Get())
+	}
+
+Reload re-reads the file on demand and reports the change with CauseReload.
+
 # Saving Configuration
 
 Update and save configuration programmatically:
@@ -49,6 +106,34 @@ Update and save configuration programmatically:
 		log.Printf("Failed to save: %v", err)
 	}
 
+Concurrent read-modify-write cycles built from Get and Save can lose each
+other's changes. Update applies a mutation to the latest value under the
+watcher's lock, re-reading the file first so external edits survive:
+
+	err := watcher.Update(func(c *Config) error {
+		c.Port = 9090
+		return nil
+	})
+
+CompareAndSave saves only if the configuration is still at the revision
+returned by Snapshot, and returns ErrConflict otherwise.
+
+# File Locking
+
+When several processes share one file, WithFileLock makes every write take
+an flock-based advisory lock on a sidecar file ("config.json.lock"),
+waiting at most the given timeout. Lock and LockFile hold the same lock
+across multi-step edits, for example in a companion CLI:
+
+	l, err := configwatcher.LockFile(ctx, "config.json")
+	if err != nil {
+		return err
+	}
+	defer l.Unlock()
+
+The lock is advisory: it only excludes processes that take it too, and a
+watcher takes it for its own writes only when built WithFileLock.
+
 # Error Handling
 
 Use WithErrorChan to receive error notifications:
@@ -66,6 +151,190 @@ Use WithErrorChan to receive error notifications:
 		}
 	}()
 
+# Layered Files
+
+WithLayers deep-merges overlay files on top of the watched file, in order:
+objects are merged key by key, scalars override, and arrays are replaced
+unless WithArrayStrategy selects appending or merging by index. Missing
+overlays are skipped, and a change to any layer reloads the configuration:
+
+	watcher, err := configwatcher.New(defaultConfig, "config.json",
+		configwatcher.WithLayers[Config]("config.prod.json", "config.local.json"))
+
+	file, _ := watcher.Source("server.port") // absolute path of config.prod.json
+
+Save writes only the values that differ from the lower layers, into the
+last overlay.
+
+# Directories
+
+WithDirectory treats the path as a conf.d-style directory. Fragments
+matching a glob pattern are deep-merged over the default value in lexical
+order and reloaded when one is added, changed or removed. A broken fragment
+is reported as a *FragmentError and keeps its last good content, without
+affecting the others:
+
+	watcher, err := configwatcher.New(defaultConfig, "/etc/myapp/conf.d",
+		configwatcher.WithDirectory[Config]("*.json"))
+
+# Environment Variables
+
+WithEnv applies environment variables on top of the file on every load.
+Fields tagged `env:"PORT"` read that variable; with a prefix, other fields
+read a name derived from their path, such as APP_SERVER_PORT. Overridden
+values are listed by Overrides and never written to the file by Save:
+
+	type Config struct {
+		Port int    `json:"port" env:"PORT"`
+		DSN  string `json:"dsn"` // APP_DSN
+	}
+
+	watcher, err := configwatcher.New(defaultConfig, "config.json",
+		configwatcher.WithEnv[Config]("APP"))
+
+# Command-Line Flags
+
+BindFlags defines a flag for every setting of T, named after its `flag`
+tag or its dotted JSON path. WithFlags then applies the flags that were set
+explicitly, above the file and the environment; unset flags never clobber
+file values, and set flags stay in effect across reloads:
+
+	configwatcher.BindFlags(flag.CommandLine, defaultConfig)
+	flag.Parse()
+	watcher, err := configwatcher.New(defaultConfig, "config.json",
+		configwatcher.WithFlags[Config](flag.CommandLine))
+
+	pinned := watcher.PinnedByFlags() // e.g. ["server.port"]
+
+# Debouncing
+
+Editors and copy tools often produce several events for one save. Use
+WithDebounce to coalesce them into a single reload once the file has been
+quiet for a while, and WithMaxWait to bound the delay for files that are
+touched continuously:
+
+	watcher := configwatcher.NewWatcher(defaultConfig, "config.json",
+		configwatcher.WithDebounce[Config](100*time.Millisecond),
+		configwatcher.WithMaxWait[Config](time.Second))
+
+# Removed Files
+
+Rename-based saves and atomic replacements are picked up automatically. If
+the file is removed or renamed away and does not come back within a short
+grace period, WithRemovePolicy decides what happens: keep the last value
+(the default), revert to the default value, recreate the file from the
+current value, or report ErrFileRemoved and wait for it to reappear.
+
+# Read-Only Files
+
+By default a missing or empty file is created from the default value on the
+first load. WithMissingFile chooses another policy: MissingFileIgnore
+reports the problem and keeps the defaults, MissingFileError fails the load.
+For files owned by operators or configuration management, WithReadOnly
+guarantees the watcher never creates or rewrites the file; Save returns
+ErrReadOnly:
+
+	watcher, err := configwatcher.New(defaultConfig, "/etc/myapp/config.json",
+		configwatcher.WithReadOnly[Config]())
+
+A file that exists but cannot be read, for example because of its
+permissions, is always reported and never overwritten.
+
+# Kubernetes Volumes
+
+ConfigMap and Secret volumes update by atomically swapping a "..data"
+symlink, which never produces an event on the configured path. WithSymlinks
+follows the symlink chain, watches every directory along it and reloads
+when the resolved target changes:
+
+	watcher := configwatcher.NewWatcher(defaultConfig, "/etc/myapp/config.json",
+		configwatcher.WithSymlinks[Config]())
+
+# Polling
+
+On NFS, SMB, FUSE and some container overlays fsnotify events never arrive.
+WithPolling checks the file's modification time, size and content hash at a
+fixed interval instead, sharing the same reload pipeline. If fsnotify cannot
+be started at all, the watcher reports a *WatchError and falls back to
+polling once per second:
+
+	watcher := configwatcher.NewWatcher(defaultConfig, "/mnt/nfs/config.json",
+		configwatcher.WithPolling[Config](2*time.Second))
+
+# Validation
+
+Implement Validator on the configuration type, or register checks with
+WithValidator, to reject bad values. Validation runs on the initial load,
+on every reload and on Save; a rejected candidate is reported as a
+*ValidationError and the last good configuration is kept:
+
+	func (c Config) Validate() error {
+		if c.Port <= 0 {
+			return errors.New("port must be positive")
+		}
+		return nil
+	}
+
+# Migrations
+
+WithMigrations registers a chain of migrations keyed on a version field.
+They run on the raw document before it is decoded into T, so old files with
+renamed or restructured keys keep loading. WithMigrationRewrite writes the
+migrated file back, optionally keeping a backup, and MigrationPath reports
+the versions the file went through:
+
+	watcher, err := configwatcher.New(defaultConfig, "config.json",
+		configwatcher.WithMigrations[Config]("version",
+			configwatcher.Migration{From: 1, To: 2, Migrate: func(doc map[string]any) error {
+				doc["dsn"] = doc["database_url"]
+				delete(doc, "database_url")
+				return nil
+			}}),
+		configwatcher.WithMigrationRewrite[Config](true))
+
+# Strict Decoding
+
+Misspelled keys are silently ignored by default. WithStrict rejects files
+with keys that match no field, listing each with its path and a "did you
+mean" suggestion in an *UnknownKeysError; WithUnknownKeys(UnknownKeysWarn)
+only reports them on the error channel. Keys are matched using the codec's
+struct tag, so this works for every codec.
+
+# JSON Schema
+
+GenerateSchema describes T as a JSON Schema (draft 2020-12), using the
+description, enum, minimum, maximum, required and default struct tags, and
+WriteSchema writes it to disk for editors. WithSchemaValidation checks each
+file against the schema before decoding it and rejects nonconforming files
+with a *SchemaError that lists every violation by JSON Pointer:
+
+	type Config struct {
+		Port int    `json:"port" minimum:"1" maximum:"65535"`
+		Mode string `json:"mode" enum:"dev,prod" description:"Run mode"`
+	}
+
+# Applying Changes
+
+WithApplier registers functions that put a new configuration into effect
+before it is stored and broadcast. If one fails, the appliers that already
+succeeded are called again with the old and new values swapped, the
+previous configuration is kept and the load fails with an *ApplyError:
+
+	watcher, err := configwatcher.New(defaultConfig, "config.json",
+		configwatcher.WithApplier(func(old, new Config) error {
+			return pool.Reconnect(new.DSN)
+		}))
+
+# Closing
+
+Call Close to stop watching the file once the Watcher is no longer needed.
+It releases the fsnotify watcher, stops the background goroutine and closes
+all subscriber channels:
+
+	defer watcher.Close()
+
+After Close, Get keeps returning the last value and Save returns ErrClosed.
+
 # Thread Safety
 
 All operations are thread-safe:
@@ -84,7 +353,30 @@ Configuration files must be valid JSON matching your struct definition:
 		"debug": true
 	}
 
-If the file doesn't exist, it will be created with the default configuration.
+Other formats are handled by a Codec. The codec is chosen from the file
+extension among registered codecs, or set explicitly with WithCodec. JSON is
+always registered; YAML and TOML codecs live in subpackages so the core
+package stays dependency-light. A .yaml, .yml, .toml or .jsonc file whose
+codec has not been registered is rejected with ErrCodecNotRegistered instead
+of being read as JSON:
+
+	import "github.com/blackorder/configwatcher/codec/yaml"
+
+	configwatcher.RegisterCodec(yaml.Codec{})
+	watcher := configwatcher.NewWatcher(defaultConfig, "config.yaml")
+
+The codec/jsonc subpackage reads JSON with comments and trailing commas.
+Because it implements Patcher, Save writes only the changed values into the
+existing document, preserving the comments and layout written by operators.
+
+Writes are atomic: the new content goes to a temp file in the same
+directory, which is fsynced and renamed over the target, so neither readers
+nor the watcher itself observe a half-written file. An existing file keeps
+its permissions and, where possible, its ownership; new files are created
+with mode 0600.
+
+If the file doesn't exist, it will be created with the default configuration
+unless WithReadOnly or another MissingFilePolicy is set.
 If the file contains invalid JSON, errors will be reported via the error channel
 and the current configuration will be preserved.
 */
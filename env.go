@@ -0,0 +1,59 @@
+package configwatcher
+
+import (
+	"os"
+	"reflect"
+	"strings"
+)
+
+// WithEnv applies environment variables on top of the file on every load.
+// A field tagged `env:"PORT"` is set from that variable. With a non-empty
+// prefix, untagged fields are also set from a name derived from their
+// path, so server.port becomes APP_SERVER_PORT for the prefix "APP"; tag a
+// field `env:"-"` to exclude it. Values are parsed into strings, numbers,
+// booleans, durations, comma-separated slices and nested structs, and a
+// value that does not parse rejects the load with an *OverrideError.
+// Overridden values are never written to the file by Save.
+func WithEnv[T any](prefix string) Option[T] {
+	return func(w *Watcher[T]) {
+		w.env = true
+		w.envPrefix = prefix
+	}
+}
+
+// applyEnv sets the fields of v that have a matching environment variable.
+func (w *Watcher[T]) applyEnv(v reflect.Value, pins map[string]pin) error {
+	for _, f := range fieldsOf(v.Type()) {
+		name := f.sf.Tag.Get("env")
+		if name == "" && w.envPrefix != "" {
+			name = envName(w.envPrefix, f.path)
+		}
+		if name == "" || name == "-" {
+			continue
+		}
+		s, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		fv, _ := fieldByIndex(v, f.index, true)
+		if err := setFromString(fv, s); err != nil {
+			return &OverrideError{Source: "env", Name: name, Err: err}
+		}
+		pins[f.path] = pin{index: f.index, source: "env:" + name}
+	}
+	return nil
+}
+
+// envName derives the variable name for the dotted path under prefix.
+func envName(prefix, path string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, path)
+	return strings.TrimSuffix(prefix, "_") + "_" + name
+}
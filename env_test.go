@@ -0,0 +1,103 @@
+package configwatcher
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type envConfig struct {
+	Name    string        `json:"name" env:"ENVTEST_NAME"`
+	Port    int           `json:"port"`
+	Timeout time.Duration `json:"timeout"`
+	Hosts   []string      `json:"hosts"`
+	Secret  string        `json:"secret" env:"-"`
+}
+
+func TestWithEnv(t *testing.T) {
+	t.Setenv("ENVTEST_NAME", "from-env")
+	t.Setenv("APP_PORT", "9090")
+	t.Setenv("APP_TIMEOUT", "2s")
+	t.Setenv("APP_HOSTS", "a,b")
+	t.Setenv("APP_SECRET", "ignored")
+
+	watcher, _ := newTestWatcher(t, envConfig{}, envConfig{Name: "file", Port: 80, Secret: "file"},
+		WithEnv[envConfig]("APP"))
+
+	want := envConfig{Name: "from-env", Port: 9090, Timeout: 2 * time.Second, Hosts: []string{"a", "b"}, Secret: "file"}
+	if got := watcher.Get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	wantOverrides := map[string]string{
+		"name":    "env:ENVTEST_NAME",
+		"port":    "env:APP_PORT",
+		"timeout": "env:APP_TIMEOUT",
+		"hosts":   "env:APP_HOSTS",
+	}
+	if got := watcher.Overrides(); !reflect.DeepEqual(got, wantOverrides) {
+		t.Errorf("Expected overrides %v, got %v", wantOverrides, got)
+	}
+}
+
+func TestWithEnvTagsOnly(t *testing.T) {
+	t.Setenv("ENVTEST_NAME", "from-env")
+	t.Setenv("APP_PORT", "9090")
+
+	watcher, _ := newTestWatcher(t, envConfig{}, envConfig{Name: "file", Port: 80}, WithEnv[envConfig](""))
+	if got := watcher.Get(); got.Name != "from-env" || got.Port != 80 {
+		t.Errorf("Expected only tagged fields to be overridden, got %+v", got)
+	}
+}
+
+func TestWithEnvSaveKeepsFileValues(t *testing.T) {
+	t.Setenv("APP_PORT", "9090")
+	watcher, configFile := newTestWatcher(t, envConfig{}, envConfig{Name: "file", Port: 80},
+		WithEnv[envConfig]("APP"))
+
+	if err := watcher.Update(func(c *envConfig) error {
+		c.Name = "saved"
+		return nil
+	}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	var onDisk envConfig
+	data, _ := os.ReadFile(configFile)
+	if err := json.Unmarshal(data, &onDisk); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if onDisk.Name != "saved" || onDisk.Port != 80 {
+		t.Errorf("Expected env value not to be persisted, got %+v", onDisk)
+	}
+	if got := watcher.Get(); got.Name != "saved" || got.Port != 9090 {
+		t.Errorf("Expected env override to stay in effect, got %+v", got)
+	}
+}
+
+func TestWithEnvInvalidValue(t *testing.T) {
+	t.Setenv("APP_PORT", "not-a-number")
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	writeJSON(t, configFile, envConfig{Port: 80})
+
+	_, err := New(envConfig{}, configFile, WithEnv[envConfig]("APP"))
+	var overrideErr *OverrideError
+	if !errors.As(err, &overrideErr) || overrideErr.Name != "APP_PORT" {
+		t.Errorf("Expected *OverrideError for APP_PORT, got %v", err)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	tests := map[string]string{
+		"server.port":  "APP_SERVER_PORT",
+		"db.max-conns": "APP_DB_MAX_CONNS",
+		"listenAddr":   "APP_LISTENADDR",
+	}
+	for path, want := range tests {
+		if got := envName("APP_", path); got != want {
+			t.Errorf("envName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
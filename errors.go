@@ -0,0 +1,177 @@
+package configwatcher
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrClosed is returned by operations on a Watcher after Close has been called.
+	ErrClosed = errors.New("configwatcher: watcher closed")
+
+	// ErrReadOnly is returned by Save on a watcher created WithReadOnly.
+	ErrReadOnly = errors.New("configwatcher: watcher is read-only")
+
+	// ErrCodecNotRegistered is wrapped in the error reported for a file
+	// whose extension, such as ".yaml", belongs to a codec subpackage that
+	// has not been passed to RegisterCodec.
+	ErrCodecNotRegistered = errors.New("configwatcher: no codec registered")
+
+	// ErrConflict is returned by CompareAndSave when the configuration has
+	// changed since the caller's revision.
+	ErrConflict = errors.New("configwatcher: configuration changed concurrently")
+
+	// ErrEmptyFile is wrapped in the *fs.PathError reported for an empty
+	// file found by the initial load.
+	ErrEmptyFile = errors.New("configwatcher: file is empty")
+
+	// ErrFileRemoved is reported under RemoveReportAndWait when the watched
+	// file is removed or renamed away and does not reappear.
+	ErrFileRemoved = errors.New("configwatcher: file removed")
+)
+
+// ParseError reports a configuration file that could not be decoded into T.
+type ParseError struct {
+	Path string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("configwatcher: parse %s: %v", e.Path, e.Err)
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// WatchError reports a failure to watch a configuration file with fsnotify.
+// The watcher falls back to polling after reporting it.
+type WatchError struct {
+	Path string
+	Err  error
+}
+
+func (e *WatchError) Error() string {
+	return fmt.Sprintf("configwatcher: watch %s: %v", e.Path, e.Err)
+}
+
+func (e *WatchError) Unwrap() error { return e.Err }
+
+// ValidationError reports a candidate configuration rejected by a Validator
+// or a function registered with WithValidator.
+type ValidationError struct {
+	Path string
+	Err  error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("configwatcher: invalid config %s: %v", e.Path, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+// FragmentError reports a conf.d fragment that could not be read or decoded.
+// The remaining fragments are still applied.
+type FragmentError struct {
+	Path string
+	Err  error
+}
+
+func (e *FragmentError) Error() string {
+	return fmt.Sprintf("configwatcher: fragment %s: %v", e.Path, e.Err)
+}
+
+func (e *FragmentError) Unwrap() error { return e.Err }
+
+// OverrideError reports an environment variable or flag whose value could
+// not be parsed into the configuration field it overrides.
+type OverrideError struct {
+	Source string
+	Name   string
+	Err    error
+}
+
+func (e *OverrideError) Error() string {
+	return fmt.Sprintf("configwatcher: %s %s: %v", e.Source, e.Name, e.Err)
+}
+
+func (e *OverrideError) Unwrap() error { return e.Err }
+
+// UnknownKey is a key in a configuration file that matches no field.
+type UnknownKey struct {
+	// Path is the dotted path of the key, such as "server.prot".
+	Path string
+	// Suggestion is the closest known key at the same level, if any is
+	// close enough to be a likely typo.
+	Suggestion string
+}
+
+func (k UnknownKey) String() string {
+	if k.Suggestion == "" {
+		return k.Path
+	}
+	return fmt.Sprintf("%s (did you mean %q?)", k.Path, k.Suggestion)
+}
+
+// UnknownKeysError lists the unknown keys found in a configuration file.
+type UnknownKeysError struct {
+	Path string
+	Keys []UnknownKey
+}
+
+func (e *UnknownKeysError) Error() string {
+	keys := make([]string, len(e.Keys))
+	for i, k := range e.Keys {
+		keys[i] = k.String()
+	}
+	return fmt.Sprintf("configwatcher: unknown keys in %s: %s", e.Path, strings.Join(keys, ", "))
+}
+
+// MigrationError reports a configuration file that could not be migrated
+// from Version to the newest schema version.
+type MigrationError struct {
+	Path    string
+	Version int
+	Err     error
+}
+
+func (e *MigrationError) Error() string {
+	return fmt.Sprintf("configwatcher: migrate %s from version %d: %v", e.Path, e.Version, e.Err)
+}
+
+func (e *MigrationError) Unwrap() error { return e.Err }
+
+// ApplyError reports a new configuration that an applier registered with
+// WithApplier failed to put into effect. The previous configuration was
+// kept, and the appliers before the failing one were rolled back; Rollback
+// joins any errors they returned while undoing their work.
+type ApplyError struct {
+	Path     string
+	Applier  int
+	Err      error
+	Rollback error
+}
+
+func (e *ApplyError) Error() string {
+	msg := fmt.Sprintf("configwatcher: applying %s failed at applier %d: %v", e.Path, e.Applier, e.Err)
+	if e.Rollback != nil {
+		msg += fmt.Sprintf(" (rollback: %v)", e.Rollback)
+	}
+	return msg
+}
+
+func (e *ApplyError) Unwrap() error { return e.Err }
+
+// SchemaError reports a configuration document that does not conform to
+// the JSON Schema generated for T.
+type SchemaError struct {
+	Path       string
+	Violations []SchemaViolation
+}
+
+func (e *SchemaError) Error() string {
+	violations := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		violations[i] = v.String()
+	}
+	return fmt.Sprintf("configwatcher: schema violations in %s: %s", e.Path, strings.Join(violations, "; "))
+}
@@ -0,0 +1,136 @@
+package configwatcher
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// BindFlags defines a flag on fs for every setting of T, to be parsed
+// before the watcher is created with WithFlags. A flag is named after the
+// field's `flag:"name"` tag or, without one, its dotted JSON path such as
+// "server.port"; `flag:"-"` skips the field. The usage text comes from the
+// `usage` or `description` tag, and defaults is shown as the default
+// value. Like flag.Var, it panics if a flag is already defined.
+func BindFlags[T any](fs *flag.FlagSet, defaults T) {
+	v := reflect.ValueOf(&defaults).Elem()
+	for _, f := range fieldsOf(v.Type()) {
+		name := flagName(f)
+		if name == "-" {
+			continue
+		}
+		usage := f.sf.Tag.Get("usage")
+		if usage == "" {
+			usage = f.sf.Tag.Get("description")
+		}
+		if usage == "" {
+			usage = "sets " + f.path
+		}
+		ff := &fieldFlag{typ: f.sf.Type}
+		if dv, ok := fieldByIndex(v, f.index, false); ok {
+			ff.value = formatFlag(dv)
+		}
+		fs.Var(ff, name, usage)
+	}
+}
+
+// WithFlags overrides file values with the flags on fs that were set
+// explicitly on the command line, at the highest precedence. Flags are
+// matched to fields by the names BindFlags uses, so flags defined by other
+// means work too when named accordingly. Unset flags never replace file
+// values, and set flags keep their values across reloads. fs must be
+// parsed before the watcher is created; Save never writes flag values to
+// the file.
+func WithFlags[T any](fs *flag.FlagSet) Option[T] {
+	return func(w *Watcher[T]) { w.flags = fs }
+}
+
+// PinnedByFlags returns the dotted paths of the values currently pinned by
+// command-line flags, in sorted order.
+func (w *Watcher[T]) PinnedByFlags() []string {
+	var paths []string
+	for path, source := range w.Overrides() {
+		if strings.HasPrefix(source, "flag:") {
+			paths = append(paths, path)
+		}
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// applyFlags sets the fields of v whose flag was set explicitly.
+func (w *Watcher[T]) applyFlags(v reflect.Value, pins map[string]pin) error {
+	set := map[string]*flag.Flag{}
+	w.flags.Visit(func(f *flag.Flag) { set[f.Name] = f })
+	if len(set) == 0 {
+		return nil
+	}
+	for _, f := range fieldsOf(v.Type()) {
+		name := flagName(f)
+		fl, ok := set[name]
+		if !ok {
+			continue
+		}
+		fv, _ := fieldByIndex(v, f.index, true)
+		if err := setFromString(fv, fl.Value.String()); err != nil {
+			return &OverrideError{Source: "flag", Name: name, Err: err}
+		}
+		pins[f.path] = pin{index: f.index, source: "flag:" + name}
+	}
+	return nil
+}
+
+// flagName returns the flag bound to f.
+func flagName(f field) string {
+	if name := f.sf.Tag.Get("flag"); name != "" {
+		return name
+	}
+	return f.path
+}
+
+// fieldFlag is the flag.Value defined by BindFlags. It keeps the raw
+// command-line text, checked against the field's type when set.
+type fieldFlag struct {
+	typ   reflect.Type
+	value string
+}
+
+func (f *fieldFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.value
+}
+
+func (f *fieldFlag) Set(s string) error {
+	if err := setFromString(reflect.New(f.typ).Elem(), s); err != nil {
+		return err
+	}
+	f.value = s
+	return nil
+}
+
+// IsBoolFlag lets boolean settings be set with a bare -name.
+func (f *fieldFlag) IsBoolFlag() bool {
+	return f.typ.Kind() == reflect.Bool
+}
+
+// formatFlag renders v the way setFromString parses it.
+func formatFlag(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Slice {
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = formatFlag(v.Index(i))
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
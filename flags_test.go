@@ -0,0 +1,125 @@
+package configwatcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type flagServer struct {
+	Host string `json:"host" usage:"address to listen on"`
+	Port int    `json:"port" flag:"port"`
+}
+
+type flagConfig struct {
+	Server  flagServer    `json:"server"`
+	Debug   bool          `json:"debug"`
+	Timeout time.Duration `json:"timeout"`
+	Secret  string        `json:"secret" flag:"-"`
+}
+
+func TestBindFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	BindFlags(fs, flagConfig{Server: flagServer{Port: 8080}, Timeout: time.Second})
+
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) { names = append(names, f.Name) })
+	want := []string{"debug", "port", "server.host", "timeout"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Expected flags %v, got %v", want, names)
+	}
+	if f := fs.Lookup("port"); f.DefValue != "8080" {
+		t.Errorf("Expected default 8080, got %q", f.DefValue)
+	}
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+	fs.PrintDefaults()
+	if !strings.Contains(out.String(), "address to listen on") {
+		t.Errorf("Expected usage from tag, got:\n%s", out.String())
+	}
+
+	if err := fs.Parse([]string{"-port", "eighty"}); err == nil {
+		t.Error("Expected invalid flag value to fail parsing")
+	}
+}
+
+func TestWithFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	BindFlags(fs, flagConfig{})
+	if err := fs.Parse([]string{"-port", "9090", "-debug"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	writeJSON(t, configFile, flagConfig{Server: flagServer{Host: "file", Port: 80}, Timeout: time.Second})
+	watcher, err := New(flagConfig{}, configFile, WithFlags[flagConfig](fs))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	want := flagConfig{Server: flagServer{Host: "file", Port: 9090}, Debug: true, Timeout: time.Second}
+	if got := watcher.Get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	if got := watcher.PinnedByFlags(); !reflect.DeepEqual(got, []string{"debug", "server.port"}) {
+		t.Errorf("Expected debug and server.port pinned, got %v", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := watcher.SubscribeChanges(ctx)
+
+	// A reload changes unpinned values but keeps the flags in effect.
+	writeJSON(t, configFile, flagConfig{Server: flagServer{Host: "reloaded", Port: 81}, Timeout: 2 * time.Second})
+	c := receiveChange(t, changes)
+	want = flagConfig{Server: flagServer{Host: "reloaded", Port: 9090}, Debug: true, Timeout: 2 * time.Second}
+	if !reflect.DeepEqual(c.New, want) {
+		t.Errorf("Expected %+v after reload, got %+v", want, c.New)
+	}
+
+	if err := watcher.Save(c.New); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	var onDisk flagConfig
+	data, _ := os.ReadFile(configFile)
+	if err := json.Unmarshal(data, &onDisk); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if onDisk.Server.Port != 81 || onDisk.Debug {
+		t.Errorf("Expected flag values not to be persisted, got %+v", onDisk)
+	}
+}
+
+func TestFlagsOverrideEnv(t *testing.T) {
+	t.Setenv("APP_SERVER_HOST", "env")
+	t.Setenv("APP_DEBUG", "false")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	BindFlags(fs, flagConfig{})
+	if err := fs.Parse([]string{"-debug"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	writeJSON(t, configFile, flagConfig{})
+	watcher, err := New(flagConfig{}, configFile, WithEnv[flagConfig]("APP"), WithFlags[flagConfig](fs))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	if got := watcher.Get(); got.Server.Host != "env" || !got.Debug {
+		t.Errorf("Expected env and flag overrides with flags winning, got %+v", got)
+	}
+	want := map[string]string{"server.host": "env:APP_SERVER_HOST", "debug": "flag:debug"}
+	if got := watcher.Overrides(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected overrides %v, got %v", want, got)
+	}
+}
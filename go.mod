@@ -3,8 +3,11 @@ module github.com/blackorder/configwatcher
 go 1.24.5
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/blackorder/chanhub v0.1.1
 	github.com/fsnotify/fsnotify v1.9.0
+	github.com/tailscale/hujson v0.0.0-20250226034555-ec1d1c113d33
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require golang.org/x/sys v0.13.0 // indirect
@@ -0,0 +1,99 @@
+// Package jsonpatch computes RFC 6902 JSON Patch operations between two
+// documents decoded into generic Go values (maps, slices and scalars).
+package jsonpatch
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Operation is a single JSON Patch operation. Old holds the value being
+// replaced or removed; it is not part of the RFC 6902 encoding.
+type Operation struct {
+	Op    string
+	Path  string
+	Value any
+	Old   any
+}
+
+// MarshalJSON encodes o per RFC 6902, omitting Old and, for "remove",
+// Value.
+func (o Operation) MarshalJSON() ([]byte, error) {
+	if o.Op == "remove" {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{o.Op, o.Path})
+	}
+	return json.Marshal(struct {
+		Op    string `json:"op"`
+		Path  string `json:"path"`
+		Value any    `json:"value"`
+	}{o.Op, o.Path, o.Value})
+}
+
+// Diff returns the operations that turn a into b. Objects are compared
+// member by member and arrays element by element when their lengths match;
+// anything else that differs is replaced as a whole.
+func Diff(a, b any) []Operation {
+	return diff(nil, "", a, b)
+}
+
+func diff(ops []Operation, path string, a, b any) []Operation {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+		for _, k := range sortedKeys(av) {
+			if _, ok := bv[k]; !ok {
+				ops = append(ops, Operation{Op: "remove", Path: path + "/" + Escape(k), Old: av[k]})
+			}
+		}
+		for _, k := range sortedKeys(bv) {
+			p := path + "/" + Escape(k)
+			if old, ok := av[k]; ok {
+				ops = diff(ops, p, old, bv[k])
+			} else {
+				ops = append(ops, Operation{Op: "add", Path: p, Value: bv[k]})
+			}
+		}
+		return ops
+	case []any:
+		bv, ok := b.([]any)
+		if !ok || len(av) != len(bv) {
+			break
+		}
+		for i := range av {
+			ops = diff(ops, path+"/"+strconv.Itoa(i), av[i], bv[i])
+		}
+		return ops
+	}
+	if reflect.DeepEqual(a, b) {
+		return ops
+	}
+	return append(ops, Operation{Op: "replace", Path: path, Value: b, Old: a})
+}
+
+// Escape encodes a reference token for use in a JSON Pointer.
+func Escape(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+// Unescape decodes a JSON Pointer reference token.
+func Unescape(token string) string {
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,62 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, s string) any {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("Failed to decode %s: %v", s, err)
+	}
+	return v
+}
+
+func TestDiff(t *testing.T) {
+	a := decode(t, `{"name":"a","port":1,"gone":true,"tags":["x","y"],"list":[1],"a/b":{"c":1}}`)
+	b := decode(t, `{"name":"a","port":2,"new":null,"tags":["x","z"],"list":[1,2],"a/b":{"c":2}}`)
+
+	got := Diff(a, b)
+	want := []Operation{
+		{Op: "remove", Path: "/gone", Old: true},
+		{Op: "replace", Path: "/a~1b/c", Value: 2.0, Old: 1.0},
+		{Op: "replace", Path: "/list", Value: []any{1.0, 2.0}, Old: []any{1.0}},
+		{Op: "add", Path: "/new", Value: nil},
+		{Op: "replace", Path: "/port", Value: 2.0, Old: 1.0},
+		{Op: "replace", Path: "/tags/1", Value: "z", Old: "y"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff mismatch:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	if ops := Diff(a, a); len(ops) != 0 {
+		t.Errorf("Expected no operations for equal documents, got %+v", ops)
+	}
+}
+
+func TestOperationMarshalJSON(t *testing.T) {
+	ops := []Operation{
+		{Op: "remove", Path: "/a", Old: 1},
+		{Op: "add", Path: "/b", Value: nil},
+	}
+	data, err := json.Marshal(ops)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := `[{"op":"remove","path":"/a"},{"op":"add","path":"/b","value":null}]`
+	if string(data) != want {
+		t.Errorf("Got %s, want %s", data, want)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	if got := Escape("a/b~c"); got != "a~1b~0c" {
+		t.Errorf("Escape = %q", got)
+	}
+	if got := Unescape("a~1b~0c"); got != "a/b~c" {
+		t.Errorf("Unescape = %q", got)
+	}
+}
@@ -0,0 +1,421 @@
+package configwatcher
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ArrayStrategy decides how an array in a higher layer combines with the
+// same array in the layers below it.
+type ArrayStrategy int
+
+const (
+	// ArrayReplace makes the higher layer's array replace the lower one.
+	// This is the default.
+	ArrayReplace ArrayStrategy = iota
+	// ArrayAppend appends the higher layer's elements to the lower ones.
+	ArrayAppend
+	// ArrayMergeByIndex deep-merges elements at the same index and keeps
+	// the extra elements of the longer array.
+	ArrayMergeByIndex
+)
+
+// WithLayers adds overlay files on top of the watched file. Layers are
+// deep-merged in order, each overriding the ones before it: objects are
+// merged key by key, scalars are overridden, and arrays follow the
+// ArrayStrategy. Each layer is decoded with the codec for its extension, a
+// missing overlay is skipped, and a change to any layer reloads the
+// configuration. Save writes only what differs from the lower layers into
+// the last overlay.
+func WithLayers[T any](overlays ...string) Option[T] {
+	return func(w *Watcher[T]) {
+		for _, f := range overlays {
+			abs, _ := filepath.Abs(f)
+			w.layers = append(w.layers, abs)
+		}
+	}
+}
+
+// WithArrayStrategy sets how arrays are combined across layers. Without
+// paths it sets the default for every array; otherwise it applies to the
+// arrays at the given dotted paths, such as "server.hosts".
+func WithArrayStrategy[T any](s ArrayStrategy, paths ...string) Option[T] {
+	return func(w *Watcher[T]) {
+		if len(paths) == 0 {
+			w.arrays.def = s
+			return
+		}
+		if w.arrays.paths == nil {
+			w.arrays.paths = make(map[string]ArrayStrategy)
+		}
+		for _, p := range paths {
+			w.arrays.paths[p] = s
+		}
+	}
+}
+
+// Source returns the absolute path of the file that the value at the dotted
+// path, such as "server.port" or "hosts[1]", was taken from. It reports
+// false for paths that are not set by any file, for objects assembled from
+// several layers, and always without WithLayers.
+func (w *Watcher[T]) Source(path string) (string, bool) {
+	prov := w.provenance.Load()
+	if prov == nil {
+		return "", false
+	}
+	for p := path; ; p = parentPath(p) {
+		if file, ok := (*prov)[p]; ok {
+			return file, true
+		}
+		if p == "" {
+			return "", false
+		}
+	}
+}
+
+// Sources returns the file each value of the current configuration was
+// taken from, keyed by dotted path. It is empty without WithLayers.
+func (w *Watcher[T]) Sources() map[string]string {
+	prov := w.provenance.Load()
+	if prov == nil {
+		return map[string]string{}
+	}
+	return maps.Clone(*prov)
+}
+
+// arrayStrategies holds the default and per-path ArrayStrategy.
+type arrayStrategies struct {
+	def   ArrayStrategy
+	paths map[string]ArrayStrategy
+}
+
+// at returns the strategy for the array at path.
+func (a arrayStrategies) at(path string) ArrayStrategy {
+	if s, ok := a.paths[path]; ok {
+		return s
+	}
+	return a.def
+}
+
+// isLayer reports whether name is one of the overlay files.
+func (w *Watcher[T]) isLayer(name string) bool {
+	for _, l := range w.layers {
+		if l == name {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeLayers merges data, the content of the watched file, with every
+// overlay and decodes the result into T, recording where each value came
+// from in prov.
+func (w *Watcher[T]) decodeLayers(data []byte, prov map[string]string) (T, error) {
+	var zero T
+	base, err := w.decodeBase(data, prov)
+	if err != nil {
+		return zero, err
+	}
+	merged, err := w.mergeLayers(base, w.layers, prov)
+	if err != nil {
+		return zero, err
+	}
+	if err := w.checkSchema(merged); err != nil {
+		return zero, err
+	}
+	return w.decodeMerged(merged)
+}
+
+// decodeMerged decodes a merged document into a copy of the default value.
+func (w *Watcher[T]) decodeMerged(merged map[string]any) (T, error) {
+	out, err := w.clone(w.defaultVal)
+	if err != nil {
+		return out, err
+	}
+	buf, err := w.codec.Marshal(merged)
+	if err == nil {
+		err = w.codec.Unmarshal(buf, &out)
+	}
+	if err != nil {
+		return out, &ParseError{Path: w.filename, Err: err}
+	}
+	return out, nil
+}
+
+// decodeBase decodes data, the content of the watched file, into a generic
+// document. In directory mode it merges the fragments over the default
+// value instead.
+func (w *Watcher[T]) decodeBase(data []byte, prov map[string]string) (map[string]any, error) {
+	if w.dirPattern != "" {
+		base, err := w.roundTrip(w.defaultVal)
+		if err != nil {
+			return nil, err
+		}
+		return w.mergeFragments(base, prov), nil
+	}
+	base, err := decodeDoc(w.codec, data)
+	if err != nil {
+		return nil, &ParseError{Path: w.filename, Err: err}
+	}
+	if err := w.checkKeys(w.filename, w.codec, base); err != nil {
+		return nil, err
+	}
+	record(prov, "", base, w.filename)
+	return base, nil
+}
+
+// mergeLayers decodes each of overlays into a generic document and
+// deep-merges it over merged. prov may be nil.
+func (w *Watcher[T]) mergeLayers(merged map[string]any, overlays []string, prov map[string]string) (map[string]any, error) {
+	for _, layer := range overlays {
+		data, err := os.ReadFile(layer)
+		if errors.Is(err, fs.ErrNotExist) || (err == nil && len(data) == 0) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		c, err := codecFor(layer)
+		if err != nil {
+			return nil, err
+		}
+		doc, err := decodeDoc(c, data)
+		if err != nil {
+			return nil, &ParseError{Path: layer, Err: err}
+		}
+		if err := w.checkKeys(layer, c, doc); err != nil {
+			return nil, err
+		}
+		merged, _ = w.merge(merged, doc, "", layer, prov).(map[string]any)
+	}
+	return merged, nil
+}
+
+// decodeDoc decodes data into a generic document. Empty input yields an
+// empty document.
+func decodeDoc(c Codec, data []byte) (map[string]any, error) {
+	doc := map[string]any{}
+	if len(data) == 0 {
+		return doc, nil
+	}
+	if err := c.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// merge deep-merges src from layer over dst at path and returns the result.
+func (w *Watcher[T]) merge(dst, src any, path, layer string, prov map[string]string) any {
+	switch s := src.(type) {
+	case map[string]any:
+		d, ok := dst.(map[string]any)
+		if !ok {
+			break
+		}
+		for k, sv := range s {
+			child := childPath(path, k)
+			if dv, ok := d[k]; ok {
+				d[k] = w.merge(dv, sv, child, layer, prov)
+				continue
+			}
+			d[k] = sv
+			record(prov, child, sv, layer)
+		}
+		return d
+	case []any:
+		d, ok := dst.([]any)
+		if !ok {
+			break
+		}
+		switch w.arrays.at(path) {
+		case ArrayReplace:
+		case ArrayAppend:
+			for i, sv := range s {
+				record(prov, indexPath(path, len(d)+i), sv, layer)
+			}
+			return append(d, s...)
+		case ArrayMergeByIndex:
+			for i, sv := range s {
+				if i < len(d) {
+					d[i] = w.merge(d[i], sv, indexPath(path, i), layer, prov)
+					continue
+				}
+				d = append(d, sv)
+				record(prov, indexPath(path, i), sv, layer)
+			}
+			return d
+		}
+	}
+	forget(prov, path)
+	record(prov, path, src, layer)
+	return src
+}
+
+// record attributes every value in v at path to layer.
+func record(prov map[string]string, path string, v any, layer string) {
+	if prov == nil {
+		return
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		if len(v) > 0 {
+			for k, sv := range v {
+				record(prov, childPath(path, k), sv, layer)
+			}
+			return
+		}
+	case []any:
+		if len(v) > 0 {
+			for i, sv := range v {
+				record(prov, indexPath(path, i), sv, layer)
+			}
+			return
+		}
+	}
+	prov[path] = layer
+}
+
+// forget drops the attributions for path and everything below it.
+func forget(prov map[string]string, path string) {
+	for p := range prov {
+		if within(p, path) {
+			delete(prov, p)
+		}
+	}
+}
+
+// writeLayers saves cfg as the difference between it and the lower layers,
+// written into the last overlay.
+func (w *Watcher[T]) writeLayers(cfg T) error {
+	top := w.layers[len(w.layers)-1]
+	data, err := os.ReadFile(w.filename)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	lower, err := w.decodeBase(data, nil)
+	if err != nil {
+		return err
+	}
+	if lower, err = w.mergeLayers(lower, w.layers[:len(w.layers)-1], nil); err != nil {
+		return err
+	}
+	// Decode the lower layers over the default value, as a load does, so
+	// values that only come from the default are not pinned in the overlay,
+	// and normalize both sides through the watched file's codec so that
+	// values decoded by different codecs compare equal.
+	lowerVal, err := w.decodeMerged(lower)
+	if err != nil {
+		return err
+	}
+	if lower, err = w.roundTrip(lowerVal); err != nil {
+		return err
+	}
+	target, err := w.roundTrip(cfg)
+	if err != nil {
+		return err
+	}
+	overlay, _, err := w.overlay(lower, target, "")
+	if err != nil {
+		return err
+	}
+	if overlay == nil {
+		overlay = map[string]any{}
+	}
+	c, err := codecFor(top)
+	if err != nil {
+		return err
+	}
+	buf, err := encodeFile(top, c, overlay)
+	if err != nil {
+		return err
+	}
+	return writeAtomic(top, buf)
+}
+
+// roundTrip encodes v and decodes it into a generic document with the
+// watched file's codec.
+func (w *Watcher[T]) roundTrip(v any) (map[string]any, error) {
+	data, err := w.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return decodeDoc(w.codec, data)
+}
+
+// overlay returns the minimal value that, merged over lower at path, yields
+// target, and whether one is needed at all.
+func (w *Watcher[T]) overlay(lower, target any, path string) (any, bool, error) {
+	switch t := target.(type) {
+	case map[string]any:
+		l, ok := lower.(map[string]any)
+		if !ok {
+			break
+		}
+		out := map[string]any{}
+		for k, tv := range t {
+			lv, ok := l[k]
+			if !ok {
+				out[k] = tv
+				continue
+			}
+			sub, changed, err := w.overlay(lv, tv, childPath(path, k))
+			if err != nil {
+				return nil, false, err
+			}
+			if changed {
+				out[k] = sub
+			}
+		}
+		return out, len(out) > 0, nil
+	case []any:
+		l, ok := lower.([]any)
+		if !ok || reflect.DeepEqual(l, t) {
+			break
+		}
+		switch w.arrays.at(path) {
+		case ArrayReplace:
+		case ArrayAppend:
+			if len(l) > len(t) || !reflect.DeepEqual(l, t[:len(l)]) {
+				return nil, false, fmt.Errorf("configwatcher: %s cannot be saved as an appended overlay", path)
+			}
+			return t[len(l):], true, nil
+		case ArrayMergeByIndex:
+			if len(l) > len(t) {
+				return nil, false, fmt.Errorf("configwatcher: %s cannot be shortened by an overlay", path)
+			}
+		}
+	}
+	if reflect.DeepEqual(lower, target) {
+		return nil, false, nil
+	}
+	return target, true, nil
+}
+
+// childPath returns the dotted path of key under path.
+func childPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// indexPath returns the dotted path of element i of the array at path.
+func indexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// parentPath strips the last key or index from a dotted path.
+func parentPath(path string) string {
+	i := strings.LastIndexAny(path, ".[")
+	if i < 0 {
+		return ""
+	}
+	return path[:i]
+}
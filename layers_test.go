@@ -0,0 +1,216 @@
+package configwatcher
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type layerServer struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+}
+
+type layerConfig struct {
+	Name    string        `json:"name"`
+	Hosts   []string      `json:"hosts"`
+	Servers []layerServer `json:"servers"`
+}
+
+func TestLayersMerge(t *testing.T) {
+	configFile := createTempConfigFile(t, TestConfig{Name: "base", Count: 1, Settings: map[string]string{"a": "1", "b": "2"}})
+	prod := filepath.Join(filepath.Dir(configFile), "config.prod.json")
+	local := filepath.Join(filepath.Dir(configFile), "config.local.json")
+	writeJSON(t, prod, map[string]any{"count": 2, "settings": map[string]string{"b": "3"}})
+
+	watcher, err := New(TestConfig{}, configFile, WithLayers[TestConfig](prod, local))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	want := TestConfig{Name: "base", Count: 2, Settings: map[string]string{"a": "1", "b": "3"}}
+	if got := watcher.Get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	sources := map[string]string{"name": configFile, "count": prod, "settings.a": configFile, "settings.b": prod}
+	for path, file := range sources {
+		if got, ok := watcher.Source(path); !ok || got != file {
+			t.Errorf("Source(%q) = %q, %v; want %q", path, got, ok, file)
+		}
+	}
+	if _, ok := watcher.Source("settings"); ok {
+		t.Error("Object assembled from several layers should have no single source")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := watcher.SubscribeChanges(ctx)
+
+	writeJSON(t, local, map[string]any{"name": "local"})
+	if c := receiveChange(t, changes); c.New.Name != "local" || c.New.Count != 2 {
+		t.Errorf("Expected local overlay to apply, got %+v", c.New)
+	}
+	if got, _ := watcher.Source("name"); got != local {
+		t.Errorf("Expected name from %s, got %s", local, got)
+	}
+
+	os.Remove(prod)
+	if c := receiveChange(t, changes); c.New.Count != 1 || c.New.Settings["b"] != "2" {
+		t.Errorf("Expected removed layer to stop applying, got %+v", c.New)
+	}
+}
+
+func TestLayersArrayStrategies(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config.json")
+	overlay := filepath.Join(dir, "config.prod.json")
+	writeJSON(t, base, layerConfig{
+		Hosts:   []string{"a"},
+		Servers: []layerServer{{Host: "one", Port: 1}, {Host: "two", Port: 2}},
+	})
+	writeJSON(t, overlay, map[string]any{
+		"hosts":   []string{"b"},
+		"servers": []map[string]any{{"port": 10}},
+	})
+
+	tests := []struct {
+		name string
+		opts []Option[layerConfig]
+		want layerConfig
+	}{
+		{"replace", nil, layerConfig{
+			Hosts:   []string{"b"},
+			Servers: []layerServer{{Port: 10}},
+		}},
+		{"append", []Option[layerConfig]{WithArrayStrategy[layerConfig](ArrayAppend)}, layerConfig{
+			Hosts:   []string{"a", "b"},
+			Servers: []layerServer{{Host: "one", Port: 1}, {Host: "two", Port: 2}, {Port: 10}},
+		}},
+		{"per path", []Option[layerConfig]{WithArrayStrategy[layerConfig](ArrayMergeByIndex, "servers")}, layerConfig{
+			Hosts:   []string{"b"},
+			Servers: []layerServer{{Host: "one", Port: 10}, {Host: "two", Port: 2}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option[layerConfig]{WithLayers[layerConfig](overlay)}, tt.opts...)
+			watcher, err := New(layerConfig{}, base, opts...)
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			defer watcher.Close()
+			if got := watcher.Get(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLayersSaveWritesOverlay(t *testing.T) {
+	configFile := createTempConfigFile(t, TestConfig{Name: "base", Count: 1})
+	local := filepath.Join(filepath.Dir(configFile), "config.local.json")
+	baseData, _ := os.ReadFile(configFile)
+
+	watcher, err := New(TestConfig{}, configFile, WithLayers[TestConfig](local))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	cfg := watcher.Get()
+	cfg.Count = 5
+	if err := watcher.Save(cfg); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if got := watcher.Get(); got.Count != 5 || got.Name != "base" {
+		t.Errorf("Expected saved value to load back, got %+v", got)
+	}
+	if data, _ := os.ReadFile(configFile); string(data) != string(baseData) {
+		t.Errorf("Base layer should be untouched, got %s", data)
+	}
+	var overlay map[string]any
+	data, _ := os.ReadFile(local)
+	if err := json.Unmarshal(data, &overlay); err != nil {
+		t.Fatalf("Overlay is not valid JSON: %v", err)
+	}
+	if want := map[string]any{"count": float64(5)}; !reflect.DeepEqual(overlay, want) {
+		t.Errorf("Expected minimal overlay %v, got %v", want, overlay)
+	}
+}
+
+func TestLayersSaveKeepsDefaultsOutOfOverlay(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.json")
+	local := filepath.Join(dir, "config.local.json")
+	writeRaw(t, configFile, `{"name":"a"}`)
+
+	watcher, err := New(TestConfig{Count: 5}, configFile, WithLayers[TestConfig](local))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	cfg := watcher.Get()
+	cfg.Name = "z"
+	if err := watcher.Save(cfg); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	var overlay map[string]any
+	data, _ := os.ReadFile(local)
+	if err := json.Unmarshal(data, &overlay); err != nil {
+		t.Fatalf("Overlay is not valid JSON: %v", err)
+	}
+	if want := map[string]any{"name": "z"}; !reflect.DeepEqual(overlay, want) {
+		t.Errorf("Expected minimal overlay %v, got %v", want, overlay)
+	}
+
+	updates := watcher.Subscribe(context.Background())
+	writeRaw(t, configFile, `{"name":"a","count":7}`)
+	select {
+	case <-updates:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for reload")
+	}
+	if got := watcher.Get(); got.Count != 7 || got.Name != "z" {
+		t.Errorf("Expected base edit to apply under the overlay, got %+v", got)
+	}
+}
+
+func TestParentPath(t *testing.T) {
+	tests := map[string]string{
+		"server.port":    "server",
+		"hosts[1]":       "hosts",
+		"servers[0].tag": "servers[0]",
+		"name":           "",
+	}
+	for path, want := range tests {
+		if got := parentPath(path); got != want {
+			t.Errorf("parentPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestLayersPolling(t *testing.T) {
+	configFile := createTempConfigFile(t, TestConfig{Name: "base"})
+	local := filepath.Join(filepath.Dir(configFile), "config.local.json")
+	watcher, err := New(TestConfig{}, configFile,
+		WithLayers[TestConfig](local), WithPolling[TestConfig](20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := watcher.SubscribeChanges(ctx)
+
+	writeJSON(t, local, map[string]any{"count": 7})
+	if c := receiveChange(t, changes); c.New.Count != 7 {
+		t.Errorf("Expected polled overlay change, got %+v", c.New)
+	}
+}
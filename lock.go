@@ -0,0 +1,167 @@
+package configwatcher
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultLockTimeout bounds how long a write waits for the file lock
+	// when WithFileLock is given no timeout.
+	defaultLockTimeout = 5 * time.Second
+
+	// lockRetryInterval is how often a contended lock is retried.
+	lockRetryInterval = 10 * time.Millisecond
+)
+
+// FileLock is an exclusive advisory lock on a configuration file, held on
+// a sidecar file named after it with a ".lock" suffix. Cooperating
+// processes that lock the same file never interleave their edits.
+type FileLock struct {
+	f       *os.File
+	once    sync.Once
+	release func()
+}
+
+// LockFile acquires the advisory lock for the configuration file filename,
+// waiting until it is free or ctx is done. It is meant for companion tools
+// and editor integrations that hold the file during multi-step edits; a
+// timeout is reported as an *fs.PathError wrapping ctx.Err(). The lock is
+// advisory: it excludes a Watcher's own writes only when that watcher was
+// built WithFileLock.
+func LockFile(ctx context.Context, filename string) (*FileLock, error) {
+	return lockFile(ctx, filename, nil)
+}
+
+// lockFile is LockFile with a hook run each time the lock is taken. When
+// held returns false the lock is released again and retried.
+func lockFile(ctx context.Context, filename string, held func(*FileLock) bool) (*FileLock, error) {
+	path := lockPath(filename)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, defaultFileMode)
+	if err != nil {
+		return nil, err
+	}
+	ticker := time.NewTicker(lockRetryInterval)
+	defer ticker.Stop()
+	for {
+		ok, err := tryLock(f)
+		if ok {
+			l := &FileLock{f: f}
+			if held == nil || held(l) {
+				return l, nil
+			}
+			err = unlock(f)
+		}
+		if err != nil {
+			_ = f.Close()
+			return nil, &fs.PathError{Op: "lock", Path: path, Err: err}
+		}
+		select {
+		case <-ctx.Done():
+			_ = f.Close()
+			return nil, &fs.PathError{Op: "lock", Path: path, Err: ctx.Err()}
+		case <-ticker.C:
+		}
+	}
+}
+
+// Unlock releases the lock. It is idempotent.
+func (l *FileLock) Unlock() error {
+	var err error
+	l.once.Do(func() {
+		if l.release != nil {
+			l.release()
+		}
+		err = unlock(l.f)
+		if cerr := l.f.Close(); err == nil {
+			err = cerr
+		}
+	})
+	return err
+}
+
+// WithFileLock makes every write to the file (Save, Update,
+// CompareAndSave, and creating or recreating it) hold the advisory lock
+// taken by LockFile and Lock, waiting at most timeout for it. A timeout of
+// zero or less uses a default of five seconds.
+func WithFileLock[T any](timeout time.Duration) Option[T] {
+	return func(w *Watcher[T]) {
+		if timeout <= 0 {
+			timeout = defaultLockTimeout
+		}
+		w.lockTimeout = timeout
+	}
+}
+
+// Lock acquires the advisory lock on the watched file, waiting until it is
+// free, ctx is done or the watcher is closed, so a sequence of edits is not
+// interleaved with writes from other processes. Writes through this watcher
+// keep working while the lock is held. The lock excludes other writers only
+// if they take it too: watchers, including this one, do so only when built
+// WithFileLock. Unlock must not be called from inside an Update function or
+// a change listener.
+func (w *Watcher[T]) Lock(ctx context.Context) (*FileLock, error) {
+	if w.closed.Load() {
+		return nil, ErrClosed
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(w.ctx, cancel)
+	defer stop()
+	l, err := lockFile(ctx, w.filename, func(l *FileLock) bool {
+		// A write holding w.mu may be waiting for the file lock, so hand
+		// it back rather than wait for w.mu while holding it.
+		if !w.mu.TryLock() {
+			return false
+		}
+		defer w.mu.Unlock()
+		if w.heldLock != nil {
+			return false
+		}
+		w.heldLock = l
+		return true
+	})
+	if err != nil {
+		if w.closed.Load() {
+			return nil, ErrClosed
+		}
+		return nil, err
+	}
+	l.release = func() {
+		w.mu.Lock()
+		w.heldLock = nil
+		w.mu.Unlock()
+	}
+	return l, nil
+}
+
+// withFileLock runs fn holding the file lock when WithFileLock is set and
+// the lock is not already held through Lock. Callers must hold w.mu.
+func (w *Watcher[T]) withFileLock(fn func() error) error {
+	if w.lockTimeout <= 0 || w.heldLock != nil {
+		return fn()
+	}
+	ctx, cancel := context.WithTimeout(w.ctx, w.lockTimeout)
+	defer cancel()
+	l, err := LockFile(ctx, w.filename)
+	if err != nil {
+		w.sendError(err)
+		return err
+	}
+	// Nested writes, such as adding default keys during Update's re-read,
+	// reuse the lock.
+	w.heldLock = l
+	defer func() {
+		w.heldLock = nil
+		_ = l.Unlock()
+	}()
+	return fn()
+}
+
+// lockPath returns the sidecar lock file for filename.
+func lockPath(filename string) string {
+	return filename + ".lock"
+}
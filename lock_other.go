@@ -0,0 +1,16 @@
+//go:build !unix || solaris || aix || illumos
+
+package configwatcher
+
+import (
+	"errors"
+	"os"
+)
+
+// tryLock reports that advisory locking is unsupported on this platform.
+func tryLock(*os.File) (bool, error) {
+	return false, errors.ErrUnsupported
+}
+
+// unlock is a no-op on platforms without advisory locking.
+func unlock(*os.File) error { return nil }
@@ -0,0 +1,182 @@
+package configwatcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func tryLockFile(t *testing.T, filename string) error {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	l, err := LockFile(ctx, filename)
+	if err == nil {
+		l.Unlock()
+	}
+	return err
+}
+
+func TestLockFileExclusive(t *testing.T) {
+	configFile := createTempConfigFile(t, TestConfig{Name: "a"})
+
+	l, err := LockFile(context.Background(), configFile)
+	if err != nil {
+		t.Fatalf("LockFile failed: %v", err)
+	}
+	if err := tryLockFile(t, configFile); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected contended lock to time out, got %v", err)
+	}
+	if err := l.Unlock(); err != nil {
+		t.Errorf("Unlock failed: %v", err)
+	}
+	if err := l.Unlock(); err != nil {
+		t.Errorf("Second Unlock should be a no-op, got %v", err)
+	}
+	if err := tryLockFile(t, configFile); err != nil {
+		t.Errorf("Expected lock to be free after Unlock, got %v", err)
+	}
+}
+
+func TestSaveWaitsForFileLock(t *testing.T) {
+	configFile := createTempConfigFile(t, TestConfig{Name: "a"})
+	watcher, err := New(TestConfig{}, configFile, WithFileLock[TestConfig](50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	l, err := LockFile(context.Background(), configFile)
+	if err != nil {
+		t.Fatalf("LockFile failed: %v", err)
+	}
+	if err := watcher.Save(TestConfig{Name: "b"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Save to time out on a held lock, got %v", err)
+	}
+	if err := watcher.Update(func(*TestConfig) error { return nil }); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Update to time out on a held lock, got %v", err)
+	}
+	if got := watcher.Get().Name; got != "a" {
+		t.Errorf("Expected no write while locked, got %q", got)
+	}
+
+	time.AfterFunc(20*time.Millisecond, func() { l.Unlock() })
+	if err := watcher.Save(TestConfig{Name: "c"}); err != nil {
+		t.Errorf("Expected Save to proceed once the lock is released, got %v", err)
+	}
+}
+
+func TestWatcherLock(t *testing.T) {
+	configFile := createTempConfigFile(t, TestConfig{Name: "a"})
+	watcher, err := New(TestConfig{}, configFile, WithFileLock[TestConfig](50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	l, err := watcher.Lock(context.Background())
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	if err := watcher.Save(TestConfig{Name: "b"}); err != nil {
+		t.Errorf("Expected Save through the lock holder to succeed, got %v", err)
+	}
+	if err := tryLockFile(t, configFile); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected lock to stay held after Save, got %v", err)
+	}
+	l.Unlock()
+	if err := tryLockFile(t, configFile); err != nil {
+		t.Errorf("Expected lock to be free after Unlock, got %v", err)
+	}
+
+	watcher.Close()
+	if _, err := watcher.Lock(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+}
+
+func TestWatcherLockConcurrentSave(t *testing.T) {
+	configFile := createTempConfigFile(t, TestConfig{Name: "a"})
+	watcher, err := New(TestConfig{}, configFile, WithFileLock[TestConfig](500*time.Millisecond))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	// Which of Lock and Save gets the file lock first varies; repeat so a
+	// lock order inversion between them shows up.
+	for i := range 10 {
+		external, err := LockFile(context.Background(), configFile)
+		if err != nil {
+			t.Fatalf("LockFile failed: %v", err)
+		}
+		saved := make(chan error, 1)
+		go func() { saved <- watcher.Save(TestConfig{Name: fmt.Sprint(i)}) }()
+		locked := make(chan error, 1)
+		go func() {
+			l, err := watcher.Lock(context.Background())
+			if err == nil {
+				err = l.Unlock()
+			}
+			locked <- err
+		}()
+
+		time.Sleep(30 * time.Millisecond)
+		external.Unlock()
+		if err := <-saved; err != nil {
+			t.Fatalf("Expected Save to succeed once the external lock was released, got %v", err)
+		}
+		if err := <-locked; err != nil {
+			t.Fatalf("Lock failed: %v", err)
+		}
+	}
+}
+
+func TestWatcherLockWaitDoesNotBlockWatcher(t *testing.T) {
+	configFile := createTempConfigFile(t, TestConfig{Name: "a"})
+	watcher, err := New(TestConfig{}, configFile, WithFileLock[TestConfig](500*time.Millisecond))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	external, err := LockFile(context.Background(), configFile)
+	if err != nil {
+		t.Fatalf("LockFile failed: %v", err)
+	}
+	defer external.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := watcher.SubscribeChanges(ctx)
+
+	locked := make(chan error, 1)
+	go func() {
+		_, err := watcher.Lock(context.Background())
+		locked <- err
+	}()
+	time.Sleep(30 * time.Millisecond)
+
+	writeJSON(t, configFile, TestConfig{Name: "b"})
+	if c := receiveChange(t, changes); c.New.Name != "b" {
+		t.Errorf("Expected reload while Lock waits, got %+v", c.New)
+	}
+
+	closed := make(chan error, 1)
+	go func() { closed <- watcher.Close() }()
+	select {
+	case err := <-locked:
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("Expected waiting Lock to return ErrClosed, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for Lock to return after Close")
+	}
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for Close while Lock waits")
+	}
+}
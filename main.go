@@ -3,10 +3,14 @@ package configwatcher
 import (
 	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
+	"flag"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/blackorder/chanhub"
 	"github.com/fsnotify/fsnotify"
@@ -20,44 +24,199 @@ func WithErrorChan[T any](ch chan<- error) Option[T] {
 	return func(w *Watcher[T]) { w.errChan = ch }
 }
 
+// WithRequireFile makes a missing or empty file an error instead of
+// creating it from the current value. Combined with New, this lets a
+// service fail fast at startup rather than silently running on defaults.
+// It is shorthand for WithMissingFile(MissingFileError).
+func WithRequireFile[T any]() Option[T] {
+	return WithMissingFile[T](MissingFileError)
+}
+
 // Watcher[T] watches a file for type T, broadcasts updates, and reports errors.
 type Watcher[T any] struct {
-	hub      *chanhub.Hub
-	value    atomic.Value
-	filename string
-	errChan  chan<- error
-	fsw      *fsnotify.Watcher
-	ctx      context.Context
-	cancel   context.CancelFunc
+	hub       *chanhub.Hub
+	value     atomic.Value
+	filename  string
+	errChan   chan<- error
+	fsw       *fsnotify.Watcher
+	ctx       context.Context
+	cancel    context.CancelFunc
+	mu        sync.Mutex
+	closed    atomic.Bool
+	closeOnce sync.Once
+	done      chan struct{}
+	revision  atomic.Uint64
+
+	listenersMu  sync.RWMutex
+	listeners    map[uint64]func(Change[T])
+	nextListener uint64
+
+	codec        Codec
+	onMissing    MissingFilePolicy
+	readOnly     bool
+	validators   []func(T) error
+	debounce     debouncer
+	removal      debouncer
+	removePolicy RemovePolicy
+	defaultVal   T
+
+	followSymlinks bool
+	links          symlinkState
+	pollInterval   time.Duration
+
+	lockTimeout time.Duration
+	heldLock    *FileLock
+
+	layers     []string
+	arrays     arrayStrategies
+	provenance atomic.Pointer[map[string]string]
+
+	dirPattern string
+	fragments  map[string][]byte
+
+	env       bool
+	envPrefix string
+	flags     *flag.FlagSet
+
+	writeDefaults bool
+	unknownKeys   UnknownKeyMode
+
+	versionField   string
+	migrations     []Migration
+	migrateRewrite bool
+	migrateBackup  bool
+	migrationPath  atomic.Pointer[[]int]
+	fileVal        T
+	pins           atomic.Pointer[map[string]pin]
+	baseDoc        []byte
+
+	schemaCheck bool
+	schema      *Schema
+
+	appliers []func(old, new T) error
+}
+
+// New creates a Watcher with defaultVal, file path, and optional settings.
+// Options are applied before the file is first read, and any failure to
+// load the initial configuration is returned instead of being reported on
+// the error channel: decode failures as *ParseError and I/O failures as
+// *fs.PathError. If fsnotify cannot watch the file, the watcher falls back
+// to polling and reports a *WatchError on the error channel.
+func New[T any](defaultVal T, filename string, opts ...Option[T]) (*Watcher[T], error) {
+	w := newWatcher(defaultVal, filename, opts)
+	if c, ok := w.codec.(noCodec); ok {
+		w.cancel()
+		return nil, c.err
+	}
+	if err := w.applyTagDefaults(); err != nil {
+		w.cancel()
+		return nil, err
+	}
+	if err := w.load(CauseInitial); err != nil {
+		w.cancel()
+		return nil, err
+	}
+	w.watch()
+	return w, nil
 }
 
 // NewWatcher creates a Watcher with defaultVal, file path, and optional settings.
+// Unlike New, it never fails: initial load errors are sent to the error
+// channel and the watcher keeps running on the default value.
 func NewWatcher[T any](defaultVal T, filename string, opts ...Option[T]) *Watcher[T] {
+	w := newWatcher(defaultVal, filename, opts)
+	w.sendError(w.applyTagDefaults())
+	if c, ok := w.codec.(noCodec); ok {
+		w.sendError(c.err)
+	} else {
+		w.sendError(w.load(CauseInitial))
+	}
+	w.watch()
+	return w
+}
+
+// newWatcher allocates a Watcher and applies opts without touching the file.
+func newWatcher[T any](defaultVal T, filename string, opts []Option[T]) *Watcher[T] {
 	absFile, _ := filepath.Abs(filename)
 	w := &Watcher[T]{
-		hub:      chanhub.New(),
-		filename: absFile,
+		hub:        chanhub.New(),
+		filename:   absFile,
+		done:       make(chan struct{}),
+		removal:    debouncer{quiet: removeGracePeriod, maxWait: removeGracePeriod},
+		defaultVal: defaultVal,
+		fileVal:    defaultVal,
 	}
+	w.ctx, w.cancel = context.WithCancel(context.Background())
 	w.value.Store(defaultVal)
-	w.load()
 	for _, opt := range opts {
 		opt(w)
 	}
+	if w.codec == nil {
+		w.codec, _ = codecFor(absFile)
+	}
+	return w
+}
 
-	// start fsnotify watcher
+// watch starts the fsnotify watcher on the file's directory, or polling if
+// requested or if fsnotify fails.
+func (w *Watcher[T]) watch() {
+	if w.pollInterval > 0 {
+		w.startPolling()
+		return
+	}
 	fsw, err := fsnotify.NewWatcher()
+	if err == nil {
+		if err = w.addDirs(fsw); err != nil {
+			_ = fsw.Close()
+		}
+	}
 	if err != nil {
-		w.sendError(err)
-	} else {
-		w.fsw = fsw
-		dir := filepath.Dir(absFile)
-		if err := w.fsw.Add(dir); err != nil {
-			w.sendError(err)
+		w.sendError(&WatchError{Path: w.filename, Err: err})
+		w.pollInterval = defaultPollInterval
+		w.startPolling()
+		return
+	}
+	w.fsw = fsw
+	if w.followSymlinks {
+		w.refreshLinks()
+	}
+	go w.watchFS()
+}
+
+// addDirs adds the directory of the watched file and of every layer to fsw.
+func (w *Watcher[T]) addDirs(fsw *fsnotify.Watcher) error {
+	seen := map[string]bool{}
+	for i, f := range append([]string{w.filename}, w.layers...) {
+		dir := filepath.Dir(f)
+		if i == 0 && w.dirPattern != "" {
+			dir = f
+		}
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		if err := fsw.Add(dir); err != nil {
+			return err
 		}
-		w.ctx, w.cancel = context.WithCancel(context.Background())
-		go w.watchFS()
 	}
-	return w
+	return nil
+}
+
+// Close stops watching the file, releases the underlying fsnotify watcher and
+// closes every channel returned by Subscribe. Get keeps returning the last
+// value; Save returns ErrClosed. Close is idempotent and safe to call
+// concurrently with other methods.
+func (w *Watcher[T]) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		w.closed.Store(true)
+		w.cancel()
+		if w.fsw != nil {
+			err = w.fsw.Close()
+		}
+		<-w.done
+	})
+	return err
 }
 
 // Get returns the current config value.
@@ -66,27 +225,101 @@ func (w *Watcher[T]) Get() T {
 }
 
 // Subscribe returns a channel that signals when the config reloads.
+// The channel is closed when ctx is done or the watcher is closed.
 func (w *Watcher[T]) Subscribe(ctx context.Context) <-chan struct{} {
-	return w.hub.Subscribe(ctx)
+	return w.hub.Subscribe(w.scope(ctx))
+}
+
+// scope derives a context that is canceled when either ctx or the watcher is done.
+func (w *Watcher[T]) scope(ctx context.Context) context.Context {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(w.ctx, cancel)
+	context.AfterFunc(ctx, func() { stop() })
+	return ctx
 }
 
-// Save writes cfg to disk and reloads. Returns any write or marshal error.
+// Save writes cfg to disk and reloads. Returns any write or marshal error,
+// ErrReadOnly under WithReadOnly, or ErrClosed if the watcher has been
+// closed.
 func (w *Watcher[T]) Save(cfg T) error {
-	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err := w.writable(); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.withFileLock(func() error { return w.saveLocked(cfg) })
+}
+
+// writable reports why Save must not write, if it must not.
+func (w *Watcher[T]) writable() error {
+	if w.closed.Load() {
+		return ErrClosed
+	}
+	if w.readOnly {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+// saveLocked is Save for callers already holding w.mu.
+func (w *Watcher[T]) saveLocked(cfg T) error {
+	if err := w.validate(cfg); err != nil {
+		w.sendError(err)
+		return err
+	}
+	write := w.writeFile
+	if len(w.layers) > 0 {
+		write = w.writeLayers
+	}
+	cfg, err := w.unpin(cfg)
+	if err == nil {
+		err = write(cfg)
+	}
 	if err != nil {
 		w.sendError(err)
 		return err
 	}
-	if err = os.WriteFile(w.filename, data, 0o600); err != nil {
+	if err := w.loadLocked(CauseSave); err != nil {
+		var applyErr *ApplyError
+		if errors.As(err, &applyErr) {
+			// Put back the file so it matches the configuration in effect.
+			w.sendError(w.restoreFile(write))
+		}
+		w.sendError(err)
+		return err
+	}
+	return nil
+}
+
+// restoreFile writes the configuration in effect back with write, after a
+// saved value failed to apply. Callers must hold w.mu.
+func (w *Watcher[T]) restoreFile(write func(T) error) error {
+	cfg, err := w.unpin(w.Get())
+	if err != nil {
+		return err
+	}
+	return write(cfg)
+}
+
+// Reload re-reads the file immediately, as if it had changed on disk.
+// Returns any read, parse or validation error, or ErrClosed if the watcher
+// has been closed.
+func (w *Watcher[T]) Reload() error {
+	if w.closed.Load() {
+		return ErrClosed
+	}
+	if err := w.load(CauseReload); err != nil {
 		w.sendError(err)
 		return err
 	}
-	w.load()
 	return nil
 }
 
 // watchFS listens for fsnotify events and reloads on relevant changes.
 func (w *Watcher[T]) watchFS() {
+	defer close(w.done)
+	defer w.debounce.stop()
+	defer w.removal.stop()
 	for {
 		select {
 		case <-w.ctx.Done():
@@ -95,9 +328,28 @@ func (w *Watcher[T]) watchFS() {
 			if !ok {
 				return
 			}
-			if ev.Name == w.filename && (ev.Op&fsnotify.Write == fsnotify.Write || ev.Op&fsnotify.Create == fsnotify.Create) {
-				w.load()
+			if w.followSymlinks && w.linkEvent(ev) {
+				w.fileChanged()
+				continue
+			}
+			if ev.Name != w.filename {
+				if w.isLayer(ev.Name) || w.isFragment(ev.Name) {
+					w.fileChanged()
+				}
+				continue
+			}
+			switch {
+			case ev.Has(fsnotify.Write) || ev.Has(fsnotify.Create):
+				w.fileChanged()
+			case ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename):
+				w.fileRemoved()
 			}
+		case <-w.debounce.C():
+			w.debounce.fired()
+			w.sendError(w.load(CauseFileEvent))
+		case <-w.removal.C():
+			w.removal.fired()
+			w.checkRemoved()
 		case err, ok := <-w.fsw.Errors:
 			if !ok {
 				return
@@ -107,42 +359,181 @@ func (w *Watcher[T]) watchFS() {
 	}
 }
 
+// fileChanged reloads the file, or schedules a reload when debouncing.
+func (w *Watcher[T]) fileChanged() {
+	if w.debounce.enabled() {
+		w.debounce.touch(time.Now())
+		return
+	}
+	w.sendError(w.load(CauseFileEvent))
+}
+
 // load reads the file, unmarshals into T, updates on change, and broadcasts.
-func (w *Watcher[T]) load() {
-	data, err := os.ReadFile(w.filename)
+func (w *Watcher[T]) load(cause Cause) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.loadLocked(cause)
+}
+
+// loadLocked is load for callers already holding w.mu. On the initial load,
+// a missing or empty file is handled according to the MissingFilePolicy.
+// Later an empty file is assumed to be mid-write and skipped until the next
+// event. An unreadable file is never overwritten. A decoded value that
+// fails validation is rejected.
+func (w *Watcher[T]) loadLocked(cause Cause) error {
+	data, err := w.readFile()
+	if err == nil && len(data) == 0 && w.dirPattern == "" {
+		if cause != CauseInitial {
+			return nil
+		}
+		err = &fs.PathError{Op: "read", Path: w.filename, Err: ErrEmptyFile}
+	}
+	var (
+		orig     = data
+		migrated map[string]any
+		steps    []int
+	)
+	if err == nil && len(w.migrations) > 0 && w.dirPattern == "" {
+		if data, migrated, steps, err = w.migrate(data); err != nil {
+			return err
+		}
+	}
 	if err != nil {
-		w.sendError(err)
-		_ = w.writeFile(w.Get())
-		return
+		if cause != CauseInitial || !(errors.Is(err, fs.ErrNotExist) || errors.Is(err, ErrEmptyFile)) {
+			return err
+		}
+		if err := w.handleMissing(err); err != nil {
+			return err
+		}
+		// Layers and overrides still apply on top of the default value.
+		if data, err = w.codec.Marshal(w.Get()); err != nil {
+			return err
+		}
 	}
-	if len(data) == 0 {
-		_ = w.writeFile(w.Get())
-		return
+	fileVal, err := w.loadDoc(data, cause)
+	if err != nil {
+		return err
 	}
-	var newVal T
-	if err := json.Unmarshal(data, &newVal); err != nil {
-		w.sendError(err)
-		return
+	if w.dirPattern == "" {
+		w.baseDoc = data
+	}
+	if len(steps) > 0 {
+		w.migrationPath.Store(&steps)
+		if w.migrateRewrite {
+			w.sendError(w.rewriteMigrated(orig, migrated, steps[0]))
+		}
 	}
-	cur := w.Get()
-	if !equal(cur, newVal) {
-		w.value.Store(newVal)
-		w.hub.Broadcast()
+	if w.writeDefaults {
+		w.sendError(w.addMissingKeys(data, fileVal))
 	}
+	return nil
 }
 
-// writeFile persists cfg without reloading.
+// loadDoc decodes data, the document of the watched file, applies layers
+// and overrides, and commits the result if it validates and applies. It
+// returns the value read from the files. Callers must hold w.mu.
+func (w *Watcher[T]) loadDoc(data []byte, cause Cause) (T, error) {
+	fileVal, prov, err := w.decode(data)
+	if err != nil {
+		return fileVal, err
+	}
+	newVal, pins, err := w.override(fileVal)
+	if err != nil {
+		return fileVal, err
+	}
+	if err := w.validate(newVal); err != nil {
+		return fileVal, err
+	}
+	if err := w.apply(w.Get(), newVal); err != nil {
+		return fileVal, err
+	}
+	w.provenance.Store(&prov)
+	w.setPins(fileVal, pins)
+	w.commit(newVal, cause)
+	return fileVal, nil
+}
+
+// readFile reads the watched file. In directory mode it only checks that
+// the directory exists and returns no data.
+func (w *Watcher[T]) readFile() ([]byte, error) {
+	if w.dirPattern != "" {
+		_, err := os.Stat(w.filename)
+		return nil, err
+	}
+	return os.ReadFile(w.filename)
+}
+
+// merging reports whether the configuration is merged from several files.
+func (w *Watcher[T]) merging() bool {
+	return len(w.layers) > 0 || w.dirPattern != ""
+}
+
+// decode decodes data, the content of the watched file, into a copy of the
+// default value, so that settings missing from the file keep their
+// defaults, merging in any layers. It also returns the file each value came
+// from.
+func (w *Watcher[T]) decode(data []byte) (T, map[string]string, error) {
+	prov := map[string]string{}
+	if w.merging() {
+		v, err := w.decodeLayers(data, prov)
+		return v, prov, err
+	}
+	v, err := w.clone(w.defaultVal)
+	if err != nil {
+		return v, nil, err
+	}
+	if w.unknownKeys != UnknownKeysIgnore || w.schemaCheck {
+		doc, err := decodeDoc(w.codec, data)
+		if err != nil {
+			return v, nil, &ParseError{Path: w.filename, Err: err}
+		}
+		if err := w.checkKeys(w.filename, w.codec, doc); err != nil {
+			return v, nil, err
+		}
+		if err := w.checkSchema(doc); err != nil {
+			return v, nil, err
+		}
+	}
+	if err := w.codec.Unmarshal(data, &v); err != nil {
+		return v, nil, &ParseError{Path: w.filename, Err: err}
+	}
+	return v, nil, nil
+}
+
+// commit stores newVal, advances the revision and notifies subscribers if
+// it differs from the current value. Callers must hold w.mu.
+func (w *Watcher[T]) commit(newVal T, cause Cause) {
+	old := w.Get()
+	if w.equal(old, newVal) {
+		return
+	}
+	w.value.Store(newVal)
+	rev := w.revision.Add(1)
+	w.hub.Broadcast()
+	delta, _ := Diff(old, newVal)
+	w.publish(Change[T]{Old: old, New: newVal, Delta: delta, Revision: rev, Time: time.Now(), Cause: cause})
+}
+
+// writeFile persists cfg to the watched file without reloading.
 func (w *Watcher[T]) writeFile(cfg T) error {
-	data, err := json.MarshalIndent(cfg, "", "  ")
+	data, err := encodeFile(w.filename, w.codec, cfg)
 	if err != nil {
-		w.sendError(err)
 		return err
 	}
-	if err := os.WriteFile(w.filename, data, 0o600); err != nil {
-		w.sendError(err)
-		return err
+	return writeAtomic(w.filename, data)
+}
+
+// encodeFile marshals v for filename, patching it into the existing file
+// when c is a Patcher and the file holds a document it can parse.
+func encodeFile(filename string, c Codec, v any) ([]byte, error) {
+	if p, ok := c.(Patcher); ok {
+		if orig, err := os.ReadFile(filename); err == nil && len(bytes.TrimSpace(orig)) > 0 {
+			if data, err := p.Patch(orig, v); err == nil {
+				return data, nil
+			}
+		}
 	}
-	return nil
+	return c.Marshal(v)
 }
 
 // sendError non-blockingly emits errors to the provided channel.
@@ -156,9 +547,9 @@ func (w *Watcher[T]) sendError(err error) {
 	}
 }
 
-// equal performs a deep equality check via JSON round-trip.
-func equal[T any](a, b T) bool {
-	ar, _ := json.Marshal(a)
-	br, _ := json.Marshal(b)
+// equal performs a deep equality check via a round-trip through the codec.
+func (w *Watcher[T]) equal(a, b T) bool {
+	ar, _ := w.codec.Marshal(a)
+	br, _ := w.codec.Marshal(b)
 	return bytes.Equal(ar, br)
 }
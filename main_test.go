@@ -3,7 +3,9 @@ package configwatcher
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sync"
@@ -43,6 +45,28 @@ func createTempConfigFile(t *testing.T, cfg TestConfig) string {
 	return configFile
 }
 
+// newTestWatcher writes initial to a temporary config.json and watches it
+// with New, closing the watcher when the test ends.
+func newTestWatcher[T any](t *testing.T, defaultVal, initial T, opts ...Option[T]) (*Watcher[T], string) {
+	t.Helper()
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	writeJSON(t, configFile, initial)
+	watcher, err := New(defaultVal, configFile, opts...)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	t.Cleanup(func() { watcher.Close() })
+	return watcher, configFile
+}
+
+// newFileWatcher is newTestWatcher for a TestConfig file whose content
+// differs from the default, TestConfig{Name: "default"}.
+func newFileWatcher(t *testing.T, opts ...Option[TestConfig]) (*Watcher[TestConfig], string) {
+	t.Helper()
+	file := TestConfig{Name: "file", Settings: map[string]string{"a": "1"}}
+	return newTestWatcher(t, TestConfig{Name: "default"}, file, opts...)
+}
+
 func TestNewWatcher(t *testing.T) {
 	defaultConfig := TestConfig{
 		Name:  "test",
@@ -83,6 +107,85 @@ func TestNewWatcherWithOptions(t *testing.T) {
 	}
 }
 
+func TestNew(t *testing.T) {
+	defaultConfig := TestConfig{Name: "default", Count: 1}
+	configFile := createTempConfigFile(t, TestConfig{Name: "file", Count: 2})
+
+	watcher, err := New(defaultConfig, configFile)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	if got := watcher.Get(); got.Name != "file" || got.Count != 2 {
+		t.Errorf("Expected file config, got %+v", got)
+	}
+}
+
+func TestNewParseError(t *testing.T) {
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configFile, []byte(`{"name": invalid}`), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	watcher, err := New(TestConfig{Name: "default"}, configFile)
+	if watcher != nil {
+		t.Error("Expected nil watcher on error")
+	}
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected *ParseError, got %T: %v", err, err)
+	}
+	if parseErr.Path != configFile {
+		t.Errorf("Expected path %s, got %s", configFile, parseErr.Path)
+	}
+}
+
+func TestNewRequireFile(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	missing := filepath.Join(tmpDir, "missing.json")
+	_, err := New(TestConfig{Name: "default"}, missing, WithRequireFile[TestConfig]())
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist, got %v", err)
+	}
+	if _, statErr := os.Stat(missing); !os.IsNotExist(statErr) {
+		t.Error("Required file should not be created")
+	}
+
+	empty := filepath.Join(tmpDir, "empty.json")
+	if err := os.WriteFile(empty, nil, 0o600); err != nil {
+		t.Fatalf("Failed to write empty file: %v", err)
+	}
+	_, err = New(TestConfig{Name: "default"}, empty, WithRequireFile[TestConfig]())
+	if !errors.Is(err, ErrEmptyFile) {
+		t.Errorf("Expected ErrEmptyFile, got %v", err)
+	}
+}
+
+func TestNewWatcherReportsInitialLoadError(t *testing.T) {
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configFile, []byte(`{"name": invalid}`), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	errChan := make(chan error, 10)
+	watcher := NewWatcher(TestConfig{Name: "default"}, configFile, WithErrorChan[TestConfig](errChan))
+	defer watcher.Close()
+
+	select {
+	case err := <-errChan:
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("Expected *ParseError, got %T: %v", err, err)
+		}
+	default:
+		t.Fatal("Initial parse error was not reported")
+	}
+}
+
 func TestWatcherGet(t *testing.T) {
 	defaultConfig := TestConfig{
 		Name:  "initial",
@@ -368,6 +471,102 @@ func TestWatcherEmptyFile(t *testing.T) {
 	}
 }
 
+func TestWatcherClose(t *testing.T) {
+	defaultConfig := TestConfig{Name: "default", Count: 1}
+	configFile := createTempConfigFile(t, defaultConfig)
+
+	watcher := NewWatcher(defaultConfig, configFile)
+	updateChan := watcher.Subscribe(context.Background())
+
+	if err := watcher.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := watcher.Close(); err != nil {
+		t.Errorf("Second Close should be a no-op, got %v", err)
+	}
+
+	// Subscriber channels must be closed
+	select {
+	case _, ok := <-updateChan:
+		if ok {
+			t.Error("Expected subscriber channel to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for subscriber channel to close")
+	}
+
+	// Subscribing after Close yields a closed channel
+	select {
+	case _, ok := <-watcher.Subscribe(context.Background()):
+		if ok {
+			t.Error("Expected closed channel after Close")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for post-Close subscription to close")
+	}
+
+	if err := watcher.Save(TestConfig{Name: "after"}); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+	if got := watcher.Get(); got.Name != "default" {
+		t.Errorf("Expected last value after Close, got %+v", got)
+	}
+}
+
+func TestWatcherCloseStopsReloads(t *testing.T) {
+	defaultConfig := TestConfig{Name: "default", Count: 1}
+	configFile := createTempConfigFile(t, defaultConfig)
+
+	watcher := NewWatcher(defaultConfig, configFile)
+	if err := watcher.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, _ := json.Marshal(TestConfig{Name: "external", Count: 2})
+	if err := os.WriteFile(configFile, data, 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	if got := watcher.Get(); got.Name != "default" {
+		t.Errorf("Closed watcher should not reload, got %+v", got)
+	}
+}
+
+func TestWatcherCloseConcurrent(t *testing.T) {
+	defaultConfig := TestConfig{Name: "concurrent", Count: 0}
+	configFile := createTempConfigFile(t, defaultConfig)
+
+	watcher := NewWatcher(defaultConfig, configFile)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			watcher.Subscribe(ctx)
+			for j := 0; j < 10; j++ {
+				_ = watcher.Get()
+				err := watcher.Save(TestConfig{Name: "concurrent", Count: id*10 + j})
+				if err != nil && !errors.Is(err, ErrClosed) {
+					t.Errorf("Unexpected save error: %v", err)
+				}
+			}
+		}(i)
+	}
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			watcher.Close()
+		}()
+	}
+
+	wg.Wait()
+}
+
 // Benchmark tests
 func BenchmarkWatcherGet(b *testing.B) {
 	config := TestConfig{Name: "benchmark", Count: 1}
@@ -0,0 +1,166 @@
+package configwatcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Migration upgrades a raw configuration document from one schema version
+// to the next. Migrate edits doc in place, for example renaming or
+// restructuring keys; the version field is updated afterwards.
+type Migration struct {
+	From    int
+	To      int
+	Migrate func(doc map[string]any) error
+}
+
+// WithMigrations registers a chain of migrations keyed on the top-level
+// version field, such as "version". Before the watched file is decoded
+// into T, migrations run on the raw document starting at its version, one
+// after another, until the newest version is reached. A file without the
+// field is at version 0. A file whose version has no migration, or is newer
+// than the newest known version, is rejected with a *MigrationError. T
+// should include the version field, with the newest version as its
+// default, so that saved files are not migrated again.
+func WithMigrations[T any](field string, migrations ...Migration) Option[T] {
+	return func(w *Watcher[T]) {
+		w.versionField = field
+		w.migrations = append(w.migrations, migrations...)
+	}
+}
+
+// WithMigrationRewrite writes a migrated file back in its new form once it
+// has loaded and validated, so migrations run only once. When backup is
+// set, the original is kept next to it as, for example,
+// "config.json.v1.bak". It has no effect under WithReadOnly or
+// WithDirectory.
+func WithMigrationRewrite[T any](backup bool) Option[T] {
+	return func(w *Watcher[T]) {
+		w.migrateRewrite = true
+		w.migrateBackup = backup
+	}
+}
+
+// MigrationPath returns the versions the most recently migrated file went
+// through, such as [1 2 3] for a file migrated from version 1 to 3, or nil
+// if no file has needed a migration.
+func (w *Watcher[T]) MigrationPath() []int {
+	if p := w.migrationPath.Load(); p != nil {
+		return append([]int(nil), *p...)
+	}
+	return nil
+}
+
+// latestVersion returns the newest version reachable by the migrations.
+func (w *Watcher[T]) latestVersion() int {
+	latest := 0
+	for _, m := range w.migrations {
+		latest = max(latest, m.To)
+	}
+	return latest
+}
+
+// migrate runs the migration chain on data. It returns the migrated
+// document and its encoding, and the versions it went through, which are
+// nil when data was already current.
+func (w *Watcher[T]) migrate(data []byte) ([]byte, map[string]any, []int, error) {
+	doc, err := decodeDoc(w.codec, data)
+	if err != nil {
+		return nil, nil, nil, &ParseError{Path: w.filename, Err: err}
+	}
+	version, err := docVersion(doc[w.versionField])
+	if err != nil {
+		return nil, nil, nil, &MigrationError{Path: w.filename, Version: version, Err: err}
+	}
+	latest := w.latestVersion()
+	if version > latest {
+		return nil, nil, nil, &MigrationError{Path: w.filename, Version: version,
+			Err: fmt.Errorf("newer than supported version %d", latest)}
+	}
+	if version == latest {
+		return data, doc, nil, nil
+	}
+	path := []int{version}
+	for version < latest {
+		m, ok := w.migrationFrom(version)
+		if !ok {
+			return nil, nil, nil, &MigrationError{Path: w.filename, Version: version,
+				Err: fmt.Errorf("no migration to version %d", latest)}
+		}
+		if err := m.Migrate(doc); err != nil {
+			return nil, nil, nil, &MigrationError{Path: w.filename, Version: version, Err: err}
+		}
+		version = m.To
+		doc[w.versionField] = version
+		path = append(path, version)
+	}
+	data, err = w.codec.Marshal(doc)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return data, doc, path, nil
+}
+
+// migrationFrom returns the migration starting at version.
+func (w *Watcher[T]) migrationFrom(version int) (Migration, bool) {
+	for _, m := range w.migrations {
+		if m.From == version && m.To > m.From {
+			return m, true
+		}
+	}
+	return Migration{}, false
+}
+
+// rewriteMigrated writes doc, the migrated form of orig, back to the file,
+// keeping orig as a backup named after its version when requested.
+// Callers must hold w.mu.
+func (w *Watcher[T]) rewriteMigrated(orig []byte, doc map[string]any, from int) error {
+	if w.readOnly || w.dirPattern != "" {
+		return nil
+	}
+	return w.withFileLock(func() error {
+		if cur, err := os.ReadFile(w.filename); err != nil || !bytes.Equal(cur, orig) {
+			// Changed since it was read; the next load migrates it again.
+			return err
+		}
+		if w.migrateBackup {
+			backup := w.filename + ".v" + strconv.Itoa(from) + ".bak"
+			if err := writeAtomic(backup, orig); err != nil {
+				return err
+			}
+		}
+		data, err := encodeFile(w.filename, w.codec, doc)
+		if err != nil {
+			return err
+		}
+		return writeAtomic(w.filename, data)
+	})
+}
+
+// docVersion interprets the version field of a decoded document. A missing
+// field is version 0.
+func docVersion(v any) (int, error) {
+	switch v := v.(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case uint64:
+		return int(v), nil
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), nil
+		}
+	case json.Number:
+		n, err := v.Int64()
+		return int(n), err
+	case string:
+		return strconv.Atoi(v)
+	}
+	return 0, fmt.Errorf("invalid version %v", v)
+}
@@ -0,0 +1,113 @@
+package configwatcher
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type migrateConfig struct {
+	Version int    `json:"version"`
+	Host    string `json:"host"`
+	Port    int    `json:"port"`
+}
+
+// v0 had "addr"; v1 renamed it to "address"; v2 split it into host/port.
+var testMigrations = []Migration{
+	{From: 0, To: 1, Migrate: func(doc map[string]any) error {
+		doc["address"] = doc["addr"]
+		delete(doc, "addr")
+		return nil
+	}},
+	{From: 1, To: 2, Migrate: func(doc map[string]any) error {
+		addr, _ := doc["address"].(map[string]any)
+		if addr == nil {
+			return errors.New("address missing")
+		}
+		doc["host"], doc["port"] = addr["host"], addr["port"]
+		delete(doc, "address")
+		return nil
+	}},
+}
+
+func TestWithMigrations(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	writeRaw(t, configFile, `{"addr": {"host": "db", "port": 5432}}`)
+
+	watcher, err := New(migrateConfig{Version: 2}, configFile,
+		WithMigrations[migrateConfig]("version", testMigrations...), WithStrict[migrateConfig]())
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	want := migrateConfig{Version: 2, Host: "db", Port: 5432}
+	if got := watcher.Get(); got != want {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	if got := watcher.MigrationPath(); !reflect.DeepEqual(got, []int{0, 1, 2}) {
+		t.Errorf("Expected migration path [0 1 2], got %v", got)
+	}
+	if data, _ := os.ReadFile(configFile); string(data) != `{"addr": {"host": "db", "port": 5432}}` {
+		t.Errorf("File should not be rewritten without WithMigrationRewrite, got %s", data)
+	}
+}
+
+func TestMigrationRewrite(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	original := `{"version": 1, "address": {"host": "db", "port": 5432}}`
+	writeRaw(t, configFile, original)
+
+	watcher, err := New(migrateConfig{Version: 2}, configFile,
+		WithMigrations[migrateConfig]("version", testMigrations...),
+		WithMigrationRewrite[migrateConfig](true))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	if backup, err := os.ReadFile(configFile + ".v1.bak"); err != nil || string(backup) != original {
+		t.Errorf("Expected backup of the original, got %q, %v", backup, err)
+	}
+	var onDisk map[string]any
+	data, _ := os.ReadFile(configFile)
+	if err := json.Unmarshal(data, &onDisk); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	want := map[string]any{"version": float64(2), "host": "db", "port": float64(5432)}
+	if !reflect.DeepEqual(onDisk, want) {
+		t.Errorf("Expected migrated file %v, got %v", want, onDisk)
+	}
+}
+
+func TestMigrationErrors(t *testing.T) {
+	tests := map[string]string{
+		"newer":      `{"version": 3}`,
+		"failing":    `{"version": 1}`,
+		"bad format": `{"version": "two"}`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			configFile := filepath.Join(t.TempDir(), "config.json")
+			writeRaw(t, configFile, content)
+			_, err := New(migrateConfig{Version: 2}, configFile,
+				WithMigrations[migrateConfig]("version", testMigrations...))
+			var migErr *MigrationError
+			if !errors.As(err, &migErr) {
+				t.Errorf("Expected *MigrationError, got %v", err)
+			}
+		})
+	}
+
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	writeRaw(t, configFile, `{}`)
+	_, err := New(migrateConfig{}, configFile,
+		WithMigrations[migrateConfig]("version", testMigrations[1]))
+	var migErr *MigrationError
+	if !errors.As(err, &migErr) || migErr.Version != 0 {
+		t.Errorf("Expected *MigrationError for a gap in the chain, got %v", err)
+	}
+}
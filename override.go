@@ -0,0 +1,255 @@
+package configwatcher
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// field describes a leaf value of a configuration struct.
+type field struct {
+	index []int
+	path  string
+	sf    reflect.StructField
+}
+
+// pin records a value overridden outside the file and what set it.
+type pin struct {
+	index  []int
+	source string
+}
+
+var (
+	durationType        = reflect.TypeFor[time.Duration]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// Overrides returns the dotted paths of values currently overridden by
+// environment variables or flags, mapped to what set them, such as
+// "env:APP_PORT" or "flag:port". Save never writes these values to the file.
+func (w *Watcher[T]) Overrides() map[string]string {
+	out := map[string]string{}
+	if pins := w.pins.Load(); pins != nil {
+		for path, p := range *pins {
+			out[path] = p.source
+		}
+	}
+	return out
+}
+
+// overriding reports whether any override source is configured.
+func (w *Watcher[T]) overriding() bool {
+	return w.env || w.flags != nil
+}
+
+// override applies the environment and then the flags to a copy of v, the
+// value read from the file, and returns the effective value together with
+// the paths it pinned.
+func (w *Watcher[T]) override(v T) (T, map[string]pin, error) {
+	if !w.overriding() {
+		return v, nil, nil
+	}
+	cfg, err := w.clone(v)
+	if err != nil {
+		return v, nil, err
+	}
+	pins := map[string]pin{}
+	rv := reflect.ValueOf(&cfg).Elem()
+	if w.env {
+		if err := w.applyEnv(rv, pins); err != nil {
+			return v, nil, err
+		}
+	}
+	if w.flags != nil {
+		if err := w.applyFlags(rv, pins); err != nil {
+			return v, nil, err
+		}
+	}
+	return cfg, pins, nil
+}
+
+// setPins records the file value and pins of a committed configuration.
+// Callers must hold w.mu.
+func (w *Watcher[T]) setPins(fileVal T, pins map[string]pin) {
+	w.fileVal = fileVal
+	w.pins.Store(&pins)
+}
+
+// unpin returns cfg with every pinned value replaced by the value from the
+// file, so that overrides are never persisted. Callers must hold w.mu.
+func (w *Watcher[T]) unpin(cfg T) (T, error) {
+	pins := w.pins.Load()
+	if pins == nil || len(*pins) == 0 {
+		return cfg, nil
+	}
+	cfg, err := w.clone(cfg)
+	if err != nil {
+		return cfg, err
+	}
+	dst := reflect.ValueOf(&cfg).Elem()
+	src := reflect.ValueOf(&w.fileVal).Elem()
+	for _, p := range *pins {
+		d, _ := fieldByIndex(dst, p.index, true)
+		if s, ok := fieldByIndex(src, p.index, false); ok {
+			d.Set(s)
+		} else {
+			d.Set(reflect.Zero(d.Type()))
+		}
+	}
+	return cfg, nil
+}
+
+// fieldsOf lists the leaf values of the struct type t, descending into
+// nested structs and pointers to structs. Paths use JSON names, like Diff.
+func fieldsOf(t reflect.Type) []field {
+	var out []field
+	collectFields(t, nil, "", &out)
+	return out
+}
+
+func collectFields(t reflect.Type, index []int, path string, out *[]field) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		idx := append(append([]int(nil), index...), i)
+		if sf.Anonymous && name == "" && isStruct(sf.Type) {
+			collectFields(sf.Type, idx, path, out)
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		child := childPath(path, name)
+		if isStruct(sf.Type) {
+			collectFields(sf.Type, idx, child, out)
+			continue
+		}
+		*out = append(*out, field{index: idx, path: child, sf: sf})
+	}
+}
+
+// isStruct reports whether t is a struct, or a pointer to one, that holds
+// nested settings rather than a single value.
+func isStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && !reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex that allocates nil pointers
+// on the way when alloc is set, and otherwise reports false for them.
+func fieldByIndex(v reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// intDigits returns the digits of an integer setting and their base.
+// Integers are decimal, so leading zeros are not read as octal, unless
+// prefixed with 0x for hexadecimal.
+func intDigits(s string) (string, int) {
+	if hex, ok := strings.CutPrefix(s, "0x"); ok {
+		return hex, 16
+	}
+	if hex, ok := strings.CutPrefix(s, "0X"); ok {
+		return hex, 16
+	}
+	return s, 10
+}
+
+// setFromString parses s into v. It handles strings, booleans, numbers,
+// durations, encoding.TextUnmarshaler, pointers and comma-separated
+// slices; anything else is decoded as JSON.
+func setFromString(v reflect.Value, s string) error {
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		digits, base := intDigits(s)
+		n, err := strconv.ParseInt(digits, base, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		digits, base := intDigits(s)
+		n, err := strconv.ParseUint(digits, base, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		if err := setFromString(elem.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Slice:
+		if strings.HasPrefix(strings.TrimSpace(s), "[") {
+			return json.Unmarshal([]byte(s), v.Addr().Interface())
+		}
+		var parts []string
+		if s != "" {
+			parts = strings.Split(s, ",")
+		}
+		out := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setFromString(out.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		v.Set(out)
+	default:
+		return json.Unmarshal([]byte(s), v.Addr().Interface())
+	}
+	return nil
+}
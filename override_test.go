@@ -0,0 +1,79 @@
+package configwatcher
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type overrideInner struct {
+	Port    int           `json:"port"`
+	Timeout time.Duration `json:"timeout"`
+}
+
+type overrideConfig struct {
+	Name   string         `json:"name"`
+	Server overrideInner  `json:"server"`
+	Extra  *overrideInner `json:"extra,omitempty"`
+	Tags   []string       `json:"tags"`
+	Addr   netip.Addr     `json:"addr"`
+	Hidden string         `json:"-"`
+	NoTag  bool
+	secret string
+}
+
+func TestFieldsOf(t *testing.T) {
+	var paths []string
+	for _, f := range fieldsOf(reflect.TypeFor[overrideConfig]()) {
+		paths = append(paths, f.path)
+	}
+	want := []string{
+		"name", "server.port", "server.timeout", "extra.port", "extra.timeout",
+		"tags", "addr", "NoTag",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("Expected paths %v, got %v", want, paths)
+	}
+}
+
+func TestSetFromString(t *testing.T) {
+	var cfg overrideConfig
+	v := reflect.ValueOf(&cfg).Elem()
+	set := func(index []int, s string) {
+		t.Helper()
+		fv, _ := fieldByIndex(v, index, true)
+		if err := setFromString(fv, s); err != nil {
+			t.Fatalf("setFromString(%q) failed: %v", s, err)
+		}
+	}
+	set([]int{0}, "app")
+	set([]int{1, 0}, "0x50")
+	set([]int{1, 1}, "1m30s")
+	set([]int{2, 0}, "9")
+	set([]int{3}, "a, b,c")
+	set([]int{4}, "10.0.0.1")
+	set([]int{6}, "true")
+
+	want := overrideConfig{
+		Name:   "app",
+		Server: overrideInner{Port: 80, Timeout: 90 * time.Second},
+		Extra:  &overrideInner{Port: 9},
+		Tags:   []string{"a", "b", "c"},
+		Addr:   netip.MustParseAddr("10.0.0.1"),
+		NoTag:  true,
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Expected %+v, got %+v", want, cfg)
+	}
+
+	fv, _ := fieldByIndex(v, []int{1, 0}, false)
+	for s, want := range map[string]int{"010": 10, "0080": 80, "-7": -7} {
+		if err := setFromString(fv, s); err != nil || fv.Int() != int64(want) {
+			t.Errorf("setFromString(%q) = %d, %v; want decimal %d", s, fv.Int(), err, want)
+		}
+	}
+	if err := setFromString(fv, "eighty"); err == nil {
+		t.Error("Expected error for an invalid int")
+	}
+}
@@ -0,0 +1,125 @@
+package configwatcher
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io/fs"
+	"os"
+	"slices"
+	"time"
+)
+
+// defaultPollInterval is used when the watcher falls back to polling
+// because fsnotify is unavailable.
+const defaultPollInterval = time.Second
+
+// WithPolling makes the watcher check the file every interval instead of
+// relying on fsnotify events, which never arrive on NFS, SMB, FUSE and some
+// container overlay filesystems. A change in modification time, size or
+// content hash reloads the file through the same pipeline as event-driven
+// watching, including debouncing and the RemovePolicy.
+func WithPolling[T any](interval time.Duration) Option[T] {
+	return func(w *Watcher[T]) { w.pollInterval = interval }
+}
+
+// fileStamp identifies a version of the file for change detection.
+type fileStamp struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+	sum     [sha256.Size]byte
+}
+
+// stampFile returns the current stamp of filename. A missing file yields
+// the zero stamp.
+func stampFile(filename string) (fileStamp, error) {
+	info, err := os.Stat(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fileStamp{}, nil
+	}
+	if err != nil {
+		return fileStamp{}, err
+	}
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fileStamp{}, nil
+	}
+	if err != nil {
+		return fileStamp{}, err
+	}
+	return fileStamp{
+		exists:  true,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		sum:     sha256.Sum256(data),
+	}, nil
+}
+
+// startPolling records the file's current stamp and starts pollFS, so
+// changes made right after the watcher is created are not missed.
+func (w *Watcher[T]) startPolling() {
+	last, _ := stampFile(w.filename)
+	go w.pollFS(last, w.stampLayers())
+}
+
+// stampLayers returns the current stamps of the overlay files and, in
+// directory mode, of the fragments.
+func (w *Watcher[T]) stampLayers() []fileStamp {
+	files := w.layers
+	if w.dirPattern != "" {
+		fragments, _ := w.fragmentFiles()
+		files = append(fragments, files...)
+	}
+	stamps := make([]fileStamp, len(files))
+	for i, f := range files {
+		stamps[i], _ = stampFile(f)
+	}
+	return stamps
+}
+
+// pollFS checks the file and its layers every poll interval and reloads on
+// changes relative to last and layers.
+func (w *Watcher[T]) pollFS(last fileStamp, layers []fileStamp) {
+	defer close(w.done)
+	defer w.debounce.stop()
+	defer w.removal.stop()
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+			if cur := w.stampLayers(); !slices.Equal(cur, layers) {
+				layers = cur
+				w.fileChanged()
+			}
+			if w.dirPattern != "" {
+				continue
+			}
+			cur, err := stampFile(w.filename)
+			if err != nil {
+				w.sendError(err)
+				continue
+			}
+			if cur == last {
+				continue
+			}
+			prev := last
+			last = cur
+			switch {
+			case cur.exists:
+				w.fileChanged()
+			case prev.exists:
+				w.fileRemoved()
+			}
+		case <-w.debounce.C():
+			w.debounce.fired()
+			w.sendError(w.load(CauseFileEvent))
+		case <-w.removal.C():
+			w.removal.fired()
+			w.checkRemoved()
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package configwatcher
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestStampFile(t *testing.T) {
+	configFile := createTempConfigFile(t, TestConfig{Name: "a"})
+
+	first, err := stampFile(configFile)
+	if err != nil || !first.exists {
+		t.Fatalf("Expected stamp of existing file, got %+v, %v", first, err)
+	}
+
+	// Same size and modification time, different content.
+	info, _ := os.Stat(configFile)
+	writeJSON(t, configFile, TestConfig{Name: "b"})
+	if err := os.Chtimes(configFile, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+	second, err := stampFile(configFile)
+	if err != nil {
+		t.Fatalf("stampFile failed: %v", err)
+	}
+	if second == first {
+		t.Error("Expected content hash to detect the change")
+	}
+
+	os.Remove(configFile)
+	if missing, err := stampFile(configFile); err != nil || missing.exists {
+		t.Errorf("Expected zero stamp for missing file, got %+v, %v", missing, err)
+	}
+}
+
+func TestWithPolling(t *testing.T) {
+	configFile := createTempConfigFile(t, TestConfig{Name: "initial"})
+	watcher, err := New(TestConfig{Name: "default"}, configFile,
+		WithPolling[TestConfig](20*time.Millisecond),
+		WithRemovePolicy[TestConfig](RemoveRevertToDefault))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+	if watcher.fsw != nil {
+		t.Error("Polling watcher should not use fsnotify")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := watcher.SubscribeChanges(ctx)
+
+	writeJSON(t, configFile, TestConfig{Name: "polled", Count: 1})
+	c := receiveChange(t, changes)
+	if c.New.Name != "polled" || c.Cause != CauseFileEvent {
+		t.Errorf("Expected polled file change, got %+v", c)
+	}
+
+	os.Remove(configFile)
+	if c := receiveChange(t, changes); c.New.Name != "default" {
+		t.Errorf("Expected removal to revert to default, got %+v", c.New)
+	}
+}
+
+func TestPollingFallback(t *testing.T) {
+	errChan := make(chan error, 10)
+	watcher := NewWatcher(TestConfig{Name: "default"}, "/non/existent/path/config.json",
+		WithErrorChan[TestConfig](errChan))
+	defer watcher.Close()
+
+	if watcher.pollInterval != defaultPollInterval {
+		t.Errorf("Expected fallback to polling, got interval %v", watcher.pollInterval)
+	}
+	var watchErr *WatchError
+	for len(errChan) > 0 {
+		if err := <-errChan; errors.As(err, &watchErr) {
+			return
+		}
+	}
+	t.Error("Expected *WatchError to be reported")
+}
@@ -0,0 +1,50 @@
+package configwatcher
+
+// MissingFilePolicy decides what the initial load does when the file is
+// missing or empty.
+type MissingFilePolicy int
+
+const (
+	// MissingFileCreate writes the default value to the file. This is the
+	// default unless WithReadOnly is set.
+	MissingFileCreate MissingFilePolicy = iota
+	// MissingFileIgnore reports the problem on the error channel, keeps
+	// the default value and leaves the file alone.
+	MissingFileIgnore
+	// MissingFileError fails the load; New returns the error.
+	MissingFileError
+)
+
+// WithMissingFile sets what the initial load does when the file is missing
+// or empty.
+func WithMissingFile[T any](p MissingFilePolicy) Option[T] {
+	return func(w *Watcher[T]) { w.onMissing = p }
+}
+
+// WithReadOnly guarantees that the watcher never creates or rewrites the
+// file: Save returns ErrReadOnly, a missing or empty file is reported but
+// left alone (MissingFileCreate behaves like MissingFileIgnore), and
+// RemoveRecreate only reports the removal. Use it for files managed by
+// operators or configuration management.
+func WithReadOnly[T any]() Option[T] {
+	return func(w *Watcher[T]) { w.readOnly = true }
+}
+
+// handleMissing applies the MissingFilePolicy to err, a missing or empty
+// file found by the initial load.
+func (w *Watcher[T]) handleMissing(err error) error {
+	switch w.onMissing {
+	case MissingFileCreate:
+		if !w.readOnly {
+			return w.withFileLock(func() error { return w.writeFile(w.Get()) })
+		}
+		w.sendError(err)
+		return nil
+	case MissingFileIgnore:
+		w.sendError(err)
+		return nil
+	case MissingFileError:
+		return err
+	}
+	return err
+}
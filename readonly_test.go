@@ -0,0 +1,136 @@
+package configwatcher
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadOnlyMissingFile(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	errChan := make(chan error, 10)
+	watcher, err := New(TestConfig{Name: "default"}, configFile,
+		WithReadOnly[TestConfig](), WithErrorChan[TestConfig](errChan))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	if _, err := os.Stat(configFile); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Read-only watcher must not create the file, stat: %v", err)
+	}
+	if got := watcher.Get(); got.Name != "default" {
+		t.Errorf("Expected default value, got %+v", got)
+	}
+	select {
+	case err := <-errChan:
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Expected missing file to be reported, got %v", err)
+		}
+	default:
+		t.Error("Expected missing file to be reported")
+	}
+
+	if err := watcher.Save(TestConfig{Name: "saved"}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly, got %v", err)
+	}
+	if _, err := os.Stat(configFile); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Save must not create the file, stat: %v", err)
+	}
+}
+
+func TestReadOnlyEmptyFile(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configFile, nil, 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	errChan := make(chan error, 10)
+	watcher, err := New(TestConfig{Name: "default"}, configFile,
+		WithReadOnly[TestConfig](), WithErrorChan[TestConfig](errChan))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	if data, _ := os.ReadFile(configFile); len(data) != 0 {
+		t.Errorf("Read-only watcher must not populate the file, got %q", data)
+	}
+	if err := <-errChan; !errors.Is(err, ErrEmptyFile) {
+		t.Errorf("Expected ErrEmptyFile, got %v", err)
+	}
+}
+
+func TestMissingFilePolicy(t *testing.T) {
+	tests := []struct {
+		policy  MissingFilePolicy
+		created bool
+		wantErr bool
+	}{
+		{MissingFileCreate, true, false},
+		{MissingFileIgnore, false, false},
+		{MissingFileError, false, true},
+	}
+	for _, tt := range tests {
+		configFile := filepath.Join(t.TempDir(), "config.json")
+		watcher, err := New(TestConfig{Name: "default"}, configFile,
+			WithMissingFile[TestConfig](tt.policy))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("policy %d: unexpected error %v", tt.policy, err)
+		}
+		if watcher != nil {
+			watcher.Close()
+		}
+		_, statErr := os.Stat(configFile)
+		if created := statErr == nil; created != tt.created {
+			t.Errorf("policy %d: created = %v, want %v", tt.policy, created, tt.created)
+		}
+	}
+}
+
+func TestReadOnlyRemoveRecreate(t *testing.T) {
+	errChan := make(chan error, 10)
+	watcher, configFile := newFileWatcher(t, WithReadOnly[TestConfig](),
+		WithRemovePolicy[TestConfig](RemoveRecreate), WithErrorChan[TestConfig](errChan))
+
+	os.Remove(configFile)
+	select {
+	case err := <-errChan:
+		if !errors.Is(err, ErrFileRemoved) {
+			t.Errorf("Expected ErrFileRemoved, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected removal to be reported")
+	}
+	if _, err := os.Stat(configFile); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Read-only watcher must not recreate the file, stat: %v", err)
+	}
+	if got := watcher.Get(); got.Name != "file" {
+		t.Errorf("Expected last value to be kept, got %+v", got)
+	}
+}
+
+func TestUnreadableFileNotOverwritten(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can read files regardless of permissions")
+	}
+	configFile := createTempConfigFile(t, TestConfig{Name: "operator"})
+	if err := os.Chmod(configFile, 0o200); err != nil {
+		t.Fatalf("Chmod failed: %v", err)
+	}
+	_, err := New(TestConfig{Name: "default"}, configFile)
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("Expected permission error, got %v", err)
+	}
+	if err := os.Chmod(configFile, 0o600); err != nil {
+		t.Fatalf("Chmod failed: %v", err)
+	}
+	var got TestConfig
+	data, _ := os.ReadFile(configFile)
+	if err := json.Unmarshal(data, &got); err != nil || got.Name != "operator" {
+		t.Errorf("Unreadable file was overwritten: %q", data)
+	}
+}
@@ -0,0 +1,93 @@
+package configwatcher
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// removeGracePeriod is how long the watcher waits after the file is
+// removed or renamed away before applying the RemovePolicy. Editors that
+// save by renaming usually recreate the file within this window.
+const removeGracePeriod = 100 * time.Millisecond
+
+// RemovePolicy decides what a Watcher does when its file is removed or
+// renamed away and does not reappear within a short grace period.
+type RemovePolicy int
+
+const (
+	// RemoveKeepLast keeps the last value and waits silently for the file
+	// to reappear. This is the default.
+	RemoveKeepLast RemovePolicy = iota
+	// RemoveRevertToDefault reverts to the default value passed to the
+	// constructor, with layers and overrides applied on top, and notifies
+	// subscribers.
+	RemoveRevertToDefault
+	// RemoveRecreate writes the last version of the file back. Under
+	// WithReadOnly it behaves like RemoveReportAndWait.
+	RemoveRecreate
+	// RemoveReportAndWait keeps the last value, reports an error wrapping
+	// ErrFileRemoved and waits for the file to reappear.
+	RemoveReportAndWait
+)
+
+// WithRemovePolicy sets what happens when the watched file is removed or
+// renamed away. Whatever the policy, a file that later reappears at the
+// same path is picked up and reloaded.
+func WithRemovePolicy[T any](p RemovePolicy) Option[T] {
+	return func(w *Watcher[T]) { w.removePolicy = p }
+}
+
+// recreate writes back the last document loaded from the watched file,
+// which holds neither layer nor override values. Callers must hold w.mu.
+func (w *Watcher[T]) recreate() error {
+	data := w.baseDoc
+	if data == nil {
+		var err error
+		if data, err = w.codec.Marshal(w.defaultVal); err != nil {
+			return err
+		}
+	}
+	return writeAtomic(w.filename, data)
+}
+
+// fileRemoved schedules a check once the grace period has passed.
+func (w *Watcher[T]) fileRemoved() {
+	w.removal.touch(time.Now())
+}
+
+// checkRemoved reloads the file if it was replaced, or applies the
+// RemovePolicy if it is still missing.
+func (w *Watcher[T]) checkRemoved() {
+	_, err := os.Stat(w.filename)
+	switch {
+	case err == nil:
+		w.sendError(w.load(CauseFileEvent))
+		return
+	case !errors.Is(err, fs.ErrNotExist):
+		w.sendError(err)
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch w.removePolicy {
+	case RemoveKeepLast:
+	case RemoveRevertToDefault:
+		// Layers and overrides still apply on top of the default value.
+		data, err := w.codec.Marshal(w.defaultVal)
+		if err == nil {
+			_, err = w.loadDoc(data, CauseFileEvent)
+		}
+		w.sendError(err)
+	case RemoveRecreate:
+		if !w.readOnly {
+			w.sendError(w.withFileLock(w.recreate))
+			return
+		}
+		w.sendError(&fs.PathError{Op: "watch", Path: w.filename, Err: ErrFileRemoved})
+	case RemoveReportAndWait:
+		w.sendError(&fs.PathError{Op: "watch", Path: w.filename, Err: ErrFileRemoved})
+	}
+}
@@ -0,0 +1,161 @@
+package configwatcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRenameOverPicksUpReplacement(t *testing.T) {
+	watcher, configFile := newFileWatcher(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := watcher.SubscribeChanges(ctx)
+
+	replacement := filepath.Join(filepath.Dir(configFile), "new.json")
+	writeJSON(t, replacement, TestConfig{Name: "replaced", Count: 2})
+	if err := os.Rename(replacement, configFile); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+
+	if c := receiveChange(t, changes); c.New.Name != "replaced" {
+		t.Errorf("Expected replacement to be loaded, got %+v", c.New)
+	}
+}
+
+func TestRenameAwayAndRecreate(t *testing.T) {
+	watcher, configFile := newFileWatcher(t, WithRemovePolicy[TestConfig](RemoveRevertToDefault))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := watcher.SubscribeChanges(ctx)
+
+	// Vim-style save: move the original aside, then write a new file.
+	if err := os.Rename(configFile, configFile+"~"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	writeJSON(t, configFile, TestConfig{Name: "vim", Count: 3})
+
+	if c := receiveChange(t, changes); c.New.Name != "vim" {
+		t.Errorf("Expected new file to be loaded, got %+v", c.New)
+	}
+	select {
+	case c := <-changes:
+		t.Errorf("File reappeared within the grace period, unexpected change %+v", c)
+	case <-time.After(3 * removeGracePeriod):
+	}
+}
+
+func TestRemovePolicies(t *testing.T) {
+	t.Run("KeepLast", func(t *testing.T) {
+		errChan := make(chan error, 10)
+		watcher, configFile := newFileWatcher(t, WithErrorChan[TestConfig](errChan))
+		os.Remove(configFile)
+		time.Sleep(3 * removeGracePeriod)
+
+		if got := watcher.Get(); got.Name != "file" {
+			t.Errorf("Expected last value, got %+v", got)
+		}
+		select {
+		case err := <-errChan:
+			t.Errorf("Unexpected error: %v", err)
+		default:
+		}
+	})
+
+	t.Run("RevertToDefault", func(t *testing.T) {
+		watcher, configFile := newFileWatcher(t, WithRemovePolicy[TestConfig](RemoveRevertToDefault))
+		changes := watcher.SubscribeChanges(context.Background())
+		os.Remove(configFile)
+
+		if c := receiveChange(t, changes); c.New.Name != "default" || c.Old.Name != "file" {
+			t.Errorf("Expected revert to default, got %+v", c)
+		}
+	})
+
+	t.Run("Recreate", func(t *testing.T) {
+		watcher, configFile := newFileWatcher(t, WithRemovePolicy[TestConfig](RemoveRecreate))
+		os.Remove(configFile)
+		time.Sleep(3 * removeGracePeriod)
+
+		if _, err := os.Stat(configFile); err != nil {
+			t.Fatalf("Expected file to be recreated: %v", err)
+		}
+		if got := watcher.Get(); got.Name != "file" {
+			t.Errorf("Expected last value, got %+v", got)
+		}
+	})
+
+	t.Run("ReportAndWait", func(t *testing.T) {
+		errChan := make(chan error, 10)
+		watcher, configFile := newFileWatcher(t,
+			WithRemovePolicy[TestConfig](RemoveReportAndWait),
+			WithErrorChan[TestConfig](errChan))
+		changes := watcher.SubscribeChanges(context.Background())
+		os.Remove(configFile)
+
+		select {
+		case err := <-errChan:
+			if !errors.Is(err, ErrFileRemoved) {
+				t.Errorf("Expected ErrFileRemoved, got %v", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timeout waiting for removal error")
+		}
+
+		writeJSON(t, configFile, TestConfig{Name: "back", Count: 4})
+		if c := receiveChange(t, changes); c.New.Name != "back" {
+			t.Errorf("Expected reappeared file to be loaded, got %+v", c.New)
+		}
+	})
+}
+
+func TestRemovePoliciesWithLayers(t *testing.T) {
+	newLayered := func(t *testing.T, p RemovePolicy) (*Watcher[TestConfig], string) {
+		t.Helper()
+		configFile := createTempConfigFile(t, TestConfig{Name: "file", Count: 80})
+		overlay := filepath.Join(filepath.Dir(configFile), "config.local.json")
+		writeJSON(t, overlay, map[string]any{"count": 443})
+		watcher, err := New(TestConfig{Name: "default"}, configFile,
+			WithLayers[TestConfig](overlay), WithRemovePolicy[TestConfig](p))
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		t.Cleanup(func() { watcher.Close() })
+		return watcher, configFile
+	}
+
+	t.Run("RevertToDefault", func(t *testing.T) {
+		watcher, configFile := newLayered(t, RemoveRevertToDefault)
+		changes := watcher.SubscribeChanges(context.Background())
+		os.Remove(configFile)
+
+		if c := receiveChange(t, changes); c.New.Name != "default" || c.New.Count != 443 {
+			t.Errorf("Expected default with the overlay applied, got %+v", c.New)
+		}
+		if got, _ := watcher.Source("count"); got != filepath.Join(filepath.Dir(configFile), "config.local.json") {
+			t.Errorf("Expected count from the overlay, got %q", got)
+		}
+	})
+
+	t.Run("Recreate", func(t *testing.T) {
+		watcher, configFile := newLayered(t, RemoveRecreate)
+		os.Remove(configFile)
+		time.Sleep(3 * removeGracePeriod)
+
+		data, err := os.ReadFile(configFile)
+		if err != nil {
+			t.Fatalf("Expected file to be recreated: %v", err)
+		}
+		var base TestConfig
+		if err := json.Unmarshal(data, &base); err != nil || base.Count != 80 {
+			t.Errorf("Expected base file without overlay values, got %s", data)
+		}
+		if got := watcher.Get(); got.Name != "file" || got.Count != 443 {
+			t.Errorf("Expected merged value to be kept, got %+v", got)
+		}
+	})
+}
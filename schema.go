@@ -0,0 +1,462 @@
+package configwatcher
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blackorder/configwatcher/internal/jsonpatch"
+)
+
+// SchemaDialect is the JSON Schema dialect produced by GenerateSchema.
+const SchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema (draft 2020-12) document describing a
+// configuration type. AdditionalProperties is either a bool or a *Schema.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 SchemaType         `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Default              any                `json:"default,omitempty"`
+
+	// exactKeys matches properties case-sensitively, for codecs whose
+	// decoders do.
+	exactKeys bool
+}
+
+// SchemaType is the "type" keyword: one JSON type, or several, such as
+// ["array", "null"] for a slice that may be nil. It encodes as a plain
+// string when it holds a single type.
+type SchemaType []string
+
+// MarshalJSON encodes a single type as a string and several as an array.
+func (t SchemaType) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// UnmarshalJSON accepts a string or an array of strings.
+func (t *SchemaType) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = SchemaType{one}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+func (t SchemaType) String() string {
+	return strings.Join(t, " or ")
+}
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// GenerateSchema describes T as a JSON Schema (draft 2020-12). Properties
+// are named by json tags. Struct tags add constraints: `description:"..."`,
+// `enum:"a,b,c"`, `minimum:"1"`, `maximum:"65535"`, `required:"true"` and
+// `default:"..."`. Structs reject unknown properties.
+func GenerateSchema[T any]() (*Schema, error) {
+	return schemaOf[T](keyMatch{tag: "json", FieldMatcher: jsonFields{}})
+}
+
+// schemaOf describes T with properties named as km names them.
+func schemaOf[T any](km keyMatch) (*Schema, error) {
+	s, err := schemaFor(reflect.TypeFor[T](), km, map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+	s.Schema = SchemaDialect
+	s.exactKeys = !km.FoldCase()
+	return s, nil
+}
+
+// WriteSchema writes the JSON Schema for T to filename, atomically, so
+// editors can offer completion and validation while operators edit the
+// configuration.
+func WriteSchema[T any](filename string) error {
+	s, err := GenerateSchema[T]()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeAtomic(filename, append(data, '\n'))
+}
+
+// WithSchemaValidation validates every file against the JSON Schema
+// generated for T before decoding it. A file that does not conform is
+// rejected with a *SchemaError listing each violation by JSON Pointer, and
+// the last good configuration is kept.
+func WithSchemaValidation[T any]() Option[T] {
+	return func(w *Watcher[T]) { w.schemaCheck = true }
+}
+
+// schemaFor describes t, naming properties as km names them. Values of
+// slice, map and pointer types may also be null, which is how nil encodes.
+func schemaFor(t reflect.Type, km keyMatch, seen map[reflect.Type]bool) (*Schema, error) {
+	s, err := typeSchema(t, km, seen)
+	if err != nil {
+		return nil, err
+	}
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		if len(s.Type) > 0 {
+			s.Type = append(s.Type, "null")
+		}
+	}
+	return s, nil
+}
+
+// typeSchema describes the values of t other than null. Types already
+// being described higher up, which only recursive types reach, are left
+// unconstrained.
+func typeSchema(t reflect.Type, km keyMatch, seen map[reflect.Type]bool) (*Schema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: SchemaType{"string"}, Format: "date-time"}, nil
+	case reflect.PointerTo(t).Implements(textMarshalerType) || t.Implements(textMarshalerType):
+		return &Schema{Type: SchemaType{"string"}}, nil
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: SchemaType{"boolean"}}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: SchemaType{"integer"}}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		zero := 0.0
+		return &Schema{Type: SchemaType{"integer"}, Minimum: &zero}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: SchemaType{"number"}}, nil
+	case reflect.String:
+		return &Schema{Type: SchemaType{"string"}}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: SchemaType{"string"}}, nil
+		}
+		items, err := schemaFor(t.Elem(), km, seen)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: SchemaType{"array"}, Items: items}, nil
+	case reflect.Map:
+		values, err := schemaFor(t.Elem(), km, seen)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: SchemaType{"object"}, AdditionalProperties: values}, nil
+	case reflect.Struct:
+		if seen[t] {
+			return &Schema{}, nil
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		s := &Schema{Type: SchemaType{"object"}, Properties: map[string]*Schema{}, AdditionalProperties: false}
+		if err := structSchema(t, km, s, seen); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	return &Schema{}, nil
+}
+
+// structSchema adds the fields of the struct type t to s, flattening
+// embedded structs as the codec does.
+func structSchema(t reflect.Type, km keyMatch, s *Schema, seen map[reflect.Type]bool) error {
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		name, opts, _ := strings.Cut(sf.Tag.Get(km.tag), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && ft.Kind() == reflect.Struct && inlined(km, name, opts) {
+			if err := structSchema(ft, km, s, seen); err != nil {
+				return err
+			}
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = km.DefaultKey(sf.Name)
+		}
+		prop, err := schemaFor(sf.Type, km, seen)
+		if err != nil {
+			return err
+		}
+		if err := applyTags(prop, sf); err != nil {
+			return fmt.Errorf("configwatcher: schema for field %s: %w", sf.Name, err)
+		}
+		s.Properties[name] = prop
+		if sf.Tag.Get("required") == "true" {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return nil
+}
+
+// applyTags adds the constraints from the struct tags of sf to s.
+func applyTags(s *Schema, sf reflect.StructField) error {
+	s.Description = sf.Tag.Get("description")
+	if enum, ok := sf.Tag.Lookup("enum"); ok {
+		for _, e := range strings.Split(enum, ",") {
+			v, err := tagValue(sf.Type, strings.TrimSpace(e))
+			if err != nil {
+				return err
+			}
+			s.Enum = append(s.Enum, v)
+		}
+	}
+	for tag, dst := range map[string]**float64{"minimum": &s.Minimum, "maximum": &s.Maximum} {
+		if v, ok := sf.Tag.Lookup(tag); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return err
+			}
+			*dst = &f
+		}
+	}
+	if def, ok := sf.Tag.Lookup("default"); ok {
+		v, err := tagValue(sf.Type, def)
+		if err != nil {
+			return err
+		}
+		s.Default = v
+	}
+	return nil
+}
+
+// tagValue parses s as a value of type t, as found in the file.
+func tagValue(t reflect.Type, s string) (any, error) {
+	v := reflect.New(t).Elem()
+	if err := setFromString(v, s); err != nil {
+		return nil, err
+	}
+	return toJSONValue(v.Interface())
+}
+
+// SchemaViolation is a single place where a document does not conform to
+// its schema.
+type SchemaViolation struct {
+	// Pointer is the JSON Pointer of the offending value, such as
+	// "/server/port".
+	Pointer string
+	Message string
+}
+
+func (v SchemaViolation) String() string {
+	pointer := v.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	return pointer + ": " + v.Message
+}
+
+// Validate checks doc, a decoded configuration document, against s and
+// returns every violation in pointer order.
+func (s *Schema) Validate(doc any) []SchemaViolation {
+	var out []SchemaViolation
+	s.validate(doc, "", !s.exactKeys, &out)
+	slices.SortStableFunc(out, func(a, b SchemaViolation) int { return strings.Compare(a.Pointer, b.Pointer) })
+	return out
+}
+
+func (s *Schema) validate(v any, ptr string, fold bool, out *[]SchemaViolation) {
+	report := func(format string, args ...any) {
+		*out = append(*out, SchemaViolation{Pointer: ptr, Message: fmt.Sprintf(format, args...)})
+	}
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(t string) bool { return hasType(v, t) }) {
+		report("expected %s, got %s", s.Type, typeName(v))
+		return
+	}
+	if v == nil {
+		return
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return sameJSON(e, v) }) {
+		report("value %s is not one of %s", jsonText(v), jsonText(s.Enum))
+	}
+	if n, ok := number(v); ok {
+		if s.Minimum != nil && n < *s.Minimum {
+			report("value %v is less than the minimum %v", n, *s.Minimum)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			report("value %v is greater than the maximum %v", n, *s.Maximum)
+		}
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				report("missing required property %q", name)
+			}
+		}
+		for k, pv := range v {
+			child := ptr + "/" + jsonpatch.Escape(k)
+			if ps := s.property(k, fold); ps != nil {
+				ps.validate(pv, child, fold, out)
+				continue
+			}
+			switch ap := s.AdditionalProperties.(type) {
+			case bool:
+				if !ap {
+					*out = append(*out, SchemaViolation{Pointer: child, Message: "unknown property"})
+				}
+			case *Schema:
+				ap.validate(pv, child, fold, out)
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, iv := range v {
+				s.Items.validate(iv, ptr+"/"+strconv.Itoa(i), fold, out)
+			}
+		}
+	}
+}
+
+// property returns the schema of the property key, or nil if there is
+// none. With fold set, keys match without regard to case, as the JSON and
+// TOML decoders do.
+func (s *Schema) property(key string, fold bool) *Schema {
+	if ps, ok := s.Properties[key]; ok || !fold {
+		return ps
+	}
+	for name, ps := range s.Properties {
+		if strings.EqualFold(name, key) {
+			return ps
+		}
+	}
+	return nil
+}
+
+// hasType reports whether v, as decoded by any codec, is of the JSON type t.
+func hasType(v any, t string) bool {
+	switch t {
+	case "null":
+		return v == nil
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "string":
+		switch v.(type) {
+		case string, time.Time:
+			return true
+		}
+		return false
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "number":
+		_, ok := number(v)
+		return ok
+	case "integer":
+		n, ok := number(v)
+		return ok && n == math.Trunc(n)
+	}
+	return true
+}
+
+// typeName returns the JSON type of v for messages.
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	if _, ok := number(v); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// number converts the numeric types produced by the codecs to float64.
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// sameJSON reports whether a and b encode to the same JSON.
+func sameJSON(a, b any) bool {
+	ab, err1 := json.Marshal(a)
+	bb, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && bytes.Equal(ab, bb)
+}
+
+// jsonText renders v as JSON for messages.
+func jsonText(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// checkSchema validates doc, the document about to be decoded, against the
+// schema for T. Callers must hold w.mu.
+func (w *Watcher[T]) checkSchema(doc map[string]any) error {
+	if !w.schemaCheck {
+		return nil
+	}
+	if w.schema == nil {
+		s, err := schemaOf[T](keyMatchFor(w.codec))
+		if err != nil {
+			return err
+		}
+		w.schema = s
+	}
+	if violations := w.schema.Validate(doc); len(violations) > 0 {
+		return &SchemaError{Path: w.filename, Violations: violations}
+	}
+	return nil
+}
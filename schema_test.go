@@ -0,0 +1,206 @@
+package configwatcher
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	yamlcodec "github.com/blackorder/configwatcher/codec/yaml"
+)
+
+type schemaServer struct {
+	Host string `json:"host" yaml:"host" description:"Address to listen on" required:"true"`
+	Port int    `json:"port" yaml:"port" minimum:"1" maximum:"65535" default:"8080"`
+}
+
+type schemaConfig struct {
+	Mode    string            `json:"mode" yaml:"mode" enum:"dev,prod"`
+	Server  schemaServer      `json:"server" yaml:"server"`
+	Peers   []schemaServer    `json:"peers" yaml:"peers"`
+	Labels  map[string]string `json:"labels" yaml:"labels"`
+	Workers uint8             `json:"workers" yaml:"workers"`
+	Started time.Time         `json:"started" yaml:"started"`
+	Secret  string            `json:"-" yaml:"-"`
+}
+
+func TestGenerateSchema(t *testing.T) {
+	s, err := GenerateSchema[schemaConfig]()
+	if err != nil {
+		t.Fatalf("GenerateSchema failed: %v", err)
+	}
+	if s.Schema != SchemaDialect || s.Type.String() != "object" || s.AdditionalProperties != false {
+		t.Errorf("Unexpected root schema %+v", s)
+	}
+	if _, ok := s.Properties["Secret"]; ok {
+		t.Error("Excluded field should not be described")
+	}
+	if got := s.Properties["mode"].Enum; !reflect.DeepEqual(got, []any{"dev", "prod"}) {
+		t.Errorf("Expected enum [dev prod], got %v", got)
+	}
+	server := s.Properties["server"]
+	if !reflect.DeepEqual(server.Required, []string{"host"}) {
+		t.Errorf("Expected host to be required, got %v", server.Required)
+	}
+	if got := server.Properties["host"].Description; got != "Address to listen on" {
+		t.Errorf("Expected description, got %q", got)
+	}
+	port := server.Properties["port"]
+	if port.Type.String() != "integer" || *port.Minimum != 1 || *port.Maximum != 65535 || !sameJSON(port.Default, 8080) {
+		t.Errorf("Unexpected port schema %+v", port)
+	}
+	if got := s.Properties["peers"].Items; got == nil || got.Type.String() != "object" {
+		t.Errorf("Expected peers to be an array of objects, got %+v", got)
+	}
+	if got := s.Properties["labels"].AdditionalProperties; got.(*Schema).Type.String() != "string" {
+		t.Errorf("Expected string labels, got %+v", got)
+	}
+	if got := s.Properties["workers"].Minimum; got == nil || *got != 0 {
+		t.Errorf("Expected unsigned minimum 0, got %v", got)
+	}
+	if got := s.Properties["started"]; got.Type.String() != "string" || got.Format != "date-time" {
+		t.Errorf("Expected date-time string, got %+v", got)
+	}
+}
+
+func TestInvalidSchemaTag(t *testing.T) {
+	type config struct {
+		Port int `json:"port" enum:"one,two"`
+	}
+	if _, err := GenerateSchema[config](); err == nil || !strings.Contains(err.Error(), "Port") {
+		t.Errorf("Expected error naming the field, got %v", err)
+	}
+}
+
+func TestWriteSchema(t *testing.T) {
+	schemaFile := filepath.Join(t.TempDir(), "config.schema.json")
+	if err := WriteSchema[schemaConfig](schemaFile); err != nil {
+		t.Fatalf("WriteSchema failed: %v", err)
+	}
+	data, err := os.ReadFile(schemaFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Schema is not valid JSON: %v", err)
+	}
+	if doc["$schema"] != SchemaDialect {
+		t.Errorf("Expected $schema %q, got %v", SchemaDialect, doc["$schema"])
+	}
+}
+
+func TestWithSchemaValidation(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	writeRaw(t, configFile, `{
+		"mode": "staging",
+		"server": {"port": 0},
+		"peers": [{"host": "a", "port": "80"}],
+		"labels": {"a/b": 1},
+		"extra": true
+	}`)
+
+	_, err := New(schemaConfig{}, configFile, WithSchemaValidation[schemaConfig]())
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("Expected *SchemaError, got %v", err)
+	}
+	want := []string{
+		"/extra: unknown property",
+		`/labels/a~1b: expected string, got number`,
+		`/mode: value "staging" is not one of ["dev","prod"]`,
+		`/peers/0/port: expected integer, got string`,
+		`/server: missing required property "host"`,
+		`/server/port: value 0 is less than the minimum 1`,
+	}
+	var got []string
+	for _, v := range schemaErr.Violations {
+		got = append(got, v.String())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected violations\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestSchemaValidationReload(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	writeRaw(t, configFile, "mode: dev\nserver:\n  host: h\n  port: 80\n")
+	errChan := make(chan error, 10)
+
+	watcher, err := New(schemaConfig{}, configFile, WithCodec[schemaConfig](yamlcodec.Codec{}),
+		WithSchemaValidation[schemaConfig](), WithErrorChan[schemaConfig](errChan))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	writeRaw(t, configFile, "mode: dev\nserver:\n  host: h\n  port: 70000\n")
+	select {
+	case err := <-errChan:
+		var schemaErr *SchemaError
+		if !errors.As(err, &schemaErr) || schemaErr.Violations[0].Pointer != "/server/port" {
+			t.Errorf("Expected violation at /server/port, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a schema error on reload")
+	}
+	if got := watcher.Get().Server.Port; got != 80 {
+		t.Errorf("Expected last good port 80 to be kept, got %d", got)
+	}
+}
+
+func TestSchemaValidationNilValues(t *testing.T) {
+	type config struct {
+		Hosts []string          `json:"hosts"`
+		Tags  map[string]string `json:"tags"`
+		Ptr   *int              `json:"ptr" minimum:"1"`
+	}
+	configFile := filepath.Join(t.TempDir(), "config.json")
+
+	watcher, err := New(config{}, configFile, WithSchemaValidation[config]())
+	if err != nil {
+		t.Fatalf("Expected the file created from the default to validate, got %v", err)
+	}
+	defer watcher.Close()
+
+	port := 8080
+	if err := watcher.Save(config{Hosts: []string{"a"}, Ptr: &port}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := watcher.Save(config{}); err != nil {
+		t.Fatalf("Expected nil values to save, got %v", err)
+	}
+	writeRaw(t, configFile, `{"hosts": null, "tags": null, "ptr": null}`)
+	if err := watcher.Reload(); err != nil {
+		t.Errorf("Expected null values to validate, got %v", err)
+	}
+	writeRaw(t, configFile, `{"hosts": [null], "ptr": 0}`)
+	var schemaErr *SchemaError
+	if err := watcher.Reload(); !errors.As(err, &schemaErr) || len(schemaErr.Violations) != 2 {
+		t.Errorf("Expected null element and minimum violations, got %v", err)
+	}
+
+	s, err := GenerateSchema[config]()
+	if err != nil {
+		t.Fatalf("GenerateSchema failed: %v", err)
+	}
+	if data, _ := json.Marshal(s.Properties["hosts"].Type); string(data) != `["array","null"]` {
+		t.Errorf(`Expected type ["array","null"], got %s`, data)
+	}
+}
+
+func TestSchemaValidationYAMLKeys(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	writeRaw(t, configFile, "mode: dev\nServer:\n  host: h\n")
+
+	_, err := New(schemaConfig{}, configFile, WithCodec[schemaConfig](yamlcodec.Codec{}),
+		WithSchemaValidation[schemaConfig]())
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) || schemaErr.Violations[0].String() != "/Server: unknown property" {
+		t.Errorf("Expected miscased YAML key to be unknown, got %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package configwatcher
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// UnknownKeyMode decides what happens to keys in the file that do not
+// correspond to any field of T.
+type UnknownKeyMode int
+
+const (
+	// UnknownKeysIgnore silently ignores unknown keys. This is the default.
+	UnknownKeysIgnore UnknownKeyMode = iota
+	// UnknownKeysWarn reports unknown keys as an *UnknownKeysError on the
+	// error channel and loads the file anyway.
+	UnknownKeysWarn
+	// UnknownKeysReject rejects a file with unknown keys, reporting an
+	// *UnknownKeysError and keeping the last good configuration.
+	UnknownKeysReject
+)
+
+// WithUnknownKeys sets how keys that match no field of T are handled. Keys
+// are matched to fields the way the codec does, using its struct tag (see
+// FieldTagger), so detection works for every codec.
+func WithUnknownKeys[T any](mode UnknownKeyMode) Option[T] {
+	return func(w *Watcher[T]) { w.unknownKeys = mode }
+}
+
+// WithStrict rejects files containing keys that match no field of T, such
+// as a misspelled "prot" for "port". It is shorthand for
+// WithUnknownKeys(UnknownKeysReject).
+func WithStrict[T any]() Option[T] {
+	return WithUnknownKeys[T](UnknownKeysReject)
+}
+
+var jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+
+// checkKeys reports the unknown keys of doc, decoded from filename with c,
+// according to the UnknownKeyMode. It returns an error only under
+// UnknownKeysReject.
+func (w *Watcher[T]) checkKeys(filename string, c Codec, doc map[string]any) error {
+	if w.unknownKeys == UnknownKeysIgnore {
+		return nil
+	}
+	var keys []UnknownKey
+	collectUnknown(doc, reflect.TypeFor[T](), keyMatchFor(c), "", &keys)
+	if len(keys) == 0 {
+		return nil
+	}
+	slices.SortFunc(keys, func(a, b UnknownKey) int { return strings.Compare(a.Path, b.Path) })
+	err := &UnknownKeysError{Path: filename, Keys: keys}
+	if w.unknownKeys == UnknownKeysWarn {
+		w.sendError(err)
+		return nil
+	}
+	return err
+}
+
+// collectUnknown walks doc alongside the type t and appends every key that
+// t has no field for.
+func collectUnknown(doc any, t reflect.Type, km keyMatch, path string, out *[]UnknownKey) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	pt := reflect.PointerTo(t)
+	if pt.Implements(jsonUnmarshalerType) || pt.Implements(textUnmarshalerType) {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := doc.(map[string]any)
+		if !ok {
+			return
+		}
+		known := map[string]reflect.StructField{}
+		knownFields(t, km, known)
+		for k, v := range m {
+			if sf, ok := known[km.fold(k)]; ok {
+				collectUnknown(v, sf.Type, km, childPath(path, k), out)
+				continue
+			}
+			*out = append(*out, UnknownKey{Path: childPath(path, k), Suggestion: suggest(k, known)})
+		}
+	case reflect.Map:
+		if m, ok := doc.(map[string]any); ok {
+			for k, v := range m {
+				collectUnknown(v, t.Elem(), km, childPath(path, k), out)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if s, ok := doc.([]any); ok {
+			for i, v := range s {
+				collectUnknown(v, t.Elem(), km, indexPath(path, i), out)
+			}
+		}
+	}
+}
+
+// knownFields adds the fields of the struct type t to known, keyed by their
+// key name as folded by km, flattening embedded structs as the codec does.
+// The field's Name is replaced by its key name.
+func knownFields(t reflect.Type, km keyMatch, known map[string]reflect.StructField) {
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		name, opts, _ := strings.Cut(sf.Tag.Get(km.tag), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && ft.Kind() == reflect.Struct && inlined(km, name, opts) {
+			knownFields(ft, km, known)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = km.DefaultKey(sf.Name)
+		}
+		sf.Name = name
+		known[km.fold(name)] = sf
+	}
+}
+
+// inlined reports whether km flattens an embedded struct whose tag has the
+// name and options given.
+func inlined(km keyMatch, name, opts string) bool {
+	return strings.Contains(opts, "inline") || (name == "" && km.InlineEmbedded())
+}
+
+// suggest returns the known key closest to key, if it is close enough to
+// be a likely typo.
+func suggest(key string, known map[string]reflect.StructField) string {
+	key = strings.ToLower(key)
+	best, bestDist := "", len(key)/3+2
+	for lower, sf := range known {
+		d := levenshtein(key, lower)
+		if d < bestDist || (d == bestDist && best != "" && sf.Name < best) {
+			best, bestDist = sf.Name, d
+		}
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,160 @@
+package configwatcher
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	yamlcodec "github.com/blackorder/configwatcher/codec/yaml"
+)
+
+type strictServer struct {
+	Host string `json:"host" yaml:"host"`
+	Port int    `json:"port" yaml:"port"`
+}
+
+type strictConfig struct {
+	Name    string                  `json:"name" yaml:"name"`
+	Server  strictServer            `json:"server" yaml:"server"`
+	Backups []strictServer          `json:"backups" yaml:"backups"`
+	Labels  map[string]string       `json:"labels" yaml:"labels"`
+	Extra   map[string]strictServer `json:"extra" yaml:"extra"`
+	Timeout string                  `yaml:"timeout_value"`
+}
+
+const strictJSON = `{
+	"name": "app",
+	"nmae": "typo",
+	"server": {"host": "h", "prot": 80},
+	"backups": [{"host": "b", "hots": "x"}],
+	"labels": {"anything": "goes"},
+	"extra": {"a": {"port": 1, "bogus": true}},
+	"Timeout": "1s"
+}`
+
+func TestWithStrict(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	writeRaw(t, configFile, strictJSON)
+
+	_, err := New(strictConfig{}, configFile, WithStrict[strictConfig]())
+	var unknown *UnknownKeysError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("Expected *UnknownKeysError, got %v", err)
+	}
+	want := []UnknownKey{
+		{Path: "backups[0].hots", Suggestion: "host"},
+		{Path: "extra.a.bogus"},
+		{Path: "nmae", Suggestion: "name"},
+		{Path: "server.prot", Suggestion: "port"},
+	}
+	if !reflect.DeepEqual(unknown.Keys, want) {
+		t.Errorf("Expected unknown keys %+v, got %+v", want, unknown.Keys)
+	}
+	if msg := err.Error(); !strings.Contains(msg, `server.prot (did you mean "port"?)`) {
+		t.Errorf("Expected suggestion in message, got %q", msg)
+	}
+}
+
+func TestUnknownKeysWarn(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	writeRaw(t, configFile, `{"name": "app", "prot": 80}`)
+	errChan := make(chan error, 10)
+
+	watcher, err := New(strictConfig{}, configFile,
+		WithUnknownKeys[strictConfig](UnknownKeysWarn), WithErrorChan[strictConfig](errChan))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+	if got := watcher.Get().Name; got != "app" {
+		t.Errorf("Expected file to load despite unknown keys, got %q", got)
+	}
+	var unknown *UnknownKeysError
+	if err := <-errChan; !errors.As(err, &unknown) || unknown.Keys[0].Path != "prot" {
+		t.Errorf("Expected warning for prot, got %v", err)
+	}
+}
+
+func TestWithStrictYAMLTags(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	writeRaw(t, configFile, "name: app\ntimeout_value: 1s\nserver:\n  port: 80\n")
+
+	watcher, err := New(strictConfig{}, configFile,
+		WithCodec[strictConfig](yamlcodec.Codec{}), WithStrict[strictConfig]())
+	if err != nil {
+		t.Fatalf("Expected YAML keys to match yaml tags, got %v", err)
+	}
+	defer watcher.Close()
+
+	writeRaw(t, configFile, "name: app\ntimeout_vaule: 1s\n")
+	var unknown *UnknownKeysError
+	if err := watcher.Reload(); !errors.As(err, &unknown) || unknown.Keys[0].Suggestion != "timeout_value" {
+		t.Errorf("Expected unknown timeout with suggestion, got %v", err)
+	}
+}
+
+func TestWithStrictYAMLCase(t *testing.T) {
+	type base struct {
+		Host string `yaml:"host"`
+	}
+	type config struct {
+		base    `yaml:",inline"`
+		Port    int `yaml:"port"`
+		Timeout string
+		Nested  strictServer
+	}
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	writeRaw(t, configFile, "host: h\nport: 80\ntimeout: 1s\nnested:\n  port: 1\n")
+
+	watcher, err := New(config{}, configFile, WithCodec[config](yamlcodec.Codec{}), WithStrict[config]())
+	if err != nil {
+		t.Fatalf("Expected keys to match the YAML decoder, got %v", err)
+	}
+	defer watcher.Close()
+
+	writeRaw(t, configFile, "host: h\nPort: 9090\nTimeout: 1s\n")
+	var unknown *UnknownKeysError
+	if err := watcher.Reload(); !errors.As(err, &unknown) {
+		t.Fatalf("Expected miscased keys to be unknown, got %v", err)
+	}
+	want := []UnknownKey{{Path: "Port", Suggestion: "port"}, {Path: "Timeout", Suggestion: "timeout"}}
+	if !reflect.DeepEqual(unknown.Keys, want) {
+		t.Errorf("Expected unknown keys %+v, got %+v", want, unknown.Keys)
+	}
+}
+
+func TestWithStrictYAMLEmbedded(t *testing.T) {
+	type Server struct {
+		Port int `yaml:"port"`
+	}
+	type config struct {
+		Server
+	}
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	writeRaw(t, configFile, "port: 80\n")
+
+	_, err := New(config{}, configFile, WithCodec[config](yamlcodec.Codec{}), WithStrict[config]())
+	var unknown *UnknownKeysError
+	if !errors.As(err, &unknown) || unknown.Keys[0].Path != "port" {
+		t.Errorf("Expected port to be unknown without ,inline, got %v", err)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "abc", 3},
+		{"port", "port", 0},
+		{"prot", "port", 2},
+		{"kitten", "sitting", 3},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
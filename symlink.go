@@ -0,0 +1,96 @@
+package configwatcher
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// maxSymlinkHops bounds symlink resolution, mirroring the kernel's limit.
+const maxSymlinkHops = 40
+
+var errSymlinkLoop = errors.New("configwatcher: too many levels of symbolic links")
+
+// WithSymlinks makes the watcher follow the symlink chain of the file and
+// watch every directory along it, reloading when the resolved target
+// changes. Kubernetes ConfigMap and Secret volumes update by atomically
+// swapping a "..data" directory symlink, which never produces an event on
+// the configured path itself; this mode detects the swap.
+func WithSymlinks[T any]() Option[T] {
+	return func(w *Watcher[T]) { w.followSymlinks = true }
+}
+
+// symlinkState tracks the resolved target of a followed symlink chain and
+// the extra directories watched for it.
+type symlinkState struct {
+	target string
+	dirs   map[string]struct{}
+}
+
+// resolveLinks follows the symlink chain starting at filename and returns
+// the real path of the final target together with the directory of every
+// hop, including the directories behind symlinked path components.
+func resolveLinks(filename string) (target string, dirs []string, err error) {
+	name := filename
+	for i := 0; i < maxSymlinkHops; i++ {
+		dirs = append(dirs, filepath.Dir(name))
+		info, err := os.Lstat(name)
+		if err != nil {
+			return "", dirs, err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			target, err := filepath.EvalSymlinks(name)
+			if err != nil {
+				return "", dirs, err
+			}
+			return target, append(dirs, filepath.Dir(target)), nil
+		}
+		dest, err := os.Readlink(name)
+		if err != nil {
+			return "", dirs, err
+		}
+		if !filepath.IsAbs(dest) {
+			dest = filepath.Join(filepath.Dir(name), dest)
+		}
+		name = dest
+	}
+	return "", dirs, errSymlinkLoop
+}
+
+// refreshLinks re-resolves the symlink chain and adjusts the watched
+// directories. It reports whether the resolved target changed.
+func (w *Watcher[T]) refreshLinks() bool {
+	target, dirs, _ := resolveLinks(w.filename)
+	want := make(map[string]struct{}, len(dirs))
+	home := filepath.Dir(w.filename)
+	for _, dir := range dirs {
+		if dir == home {
+			continue
+		}
+		want[dir] = struct{}{}
+		if _, ok := w.links.dirs[dir]; !ok {
+			_ = w.fsw.Add(dir)
+		}
+	}
+	for dir := range w.links.dirs {
+		if _, ok := want[dir]; !ok {
+			_ = w.fsw.Remove(dir)
+		}
+	}
+	w.links.dirs = want
+	changed := target != w.links.target
+	w.links.target = target
+	return changed
+}
+
+// linkEvent reports whether ev, received in symlink mode, should reload the
+// file: either the chain now resolves to a different target, or the
+// current target itself was written.
+func (w *Watcher[T]) linkEvent(ev fsnotify.Event) bool {
+	if w.refreshLinks() {
+		return w.links.target != ""
+	}
+	return ev.Name == w.links.target && (ev.Has(fsnotify.Write) || ev.Has(fsnotify.Create))
+}
@@ -0,0 +1,135 @@
+package configwatcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// configMapDir mimics the layout of a Kubernetes ConfigMap volume:
+//
+//	config.json -> ..data/config.json
+//	..data      -> ..<version>
+//	..<version>/config.json
+type configMapDir struct {
+	t   *testing.T
+	dir string
+}
+
+func newConfigMapDir(t *testing.T, cfg TestConfig) *configMapDir {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require privileges on Windows")
+	}
+	m := &configMapDir{t: t, dir: t.TempDir()}
+	m.writeVersion("..v1", cfg)
+	m.symlink("..v1", "..data")
+	m.symlink(filepath.Join("..data", "config.json"), "config.json")
+	return m
+}
+
+func (m *configMapDir) path(name string) string {
+	return filepath.Join(m.dir, name)
+}
+
+func (m *configMapDir) symlink(dest, name string) {
+	m.t.Helper()
+	if err := os.Symlink(dest, m.path(name)); err != nil {
+		m.t.Fatalf("Symlink failed: %v", err)
+	}
+}
+
+func (m *configMapDir) writeVersion(version string, cfg TestConfig) {
+	m.t.Helper()
+	if err := os.Mkdir(m.path(version), 0o755); err != nil {
+		m.t.Fatalf("Mkdir failed: %v", err)
+	}
+	writeJSON(m.t, filepath.Join(m.path(version), "config.json"), cfg)
+}
+
+// swap publishes a new version the way the kubelet does: write a new
+// directory, atomically replace the ..data link and drop the old version.
+func (m *configMapDir) swap(oldVersion, newVersion string, cfg TestConfig) {
+	m.t.Helper()
+	m.writeVersion(newVersion, cfg)
+	m.symlink(newVersion, "..data_tmp")
+	if err := os.Rename(m.path("..data_tmp"), m.path("..data")); err != nil {
+		m.t.Fatalf("Rename failed: %v", err)
+	}
+	if err := os.RemoveAll(m.path(oldVersion)); err != nil {
+		m.t.Fatalf("RemoveAll failed: %v", err)
+	}
+}
+
+func TestResolveLinks(t *testing.T) {
+	m := newConfigMapDir(t, TestConfig{Name: "v1"})
+
+	target, dirs, err := resolveLinks(m.path("config.json"))
+	if err != nil {
+		t.Fatalf("resolveLinks failed: %v", err)
+	}
+	want, _ := filepath.EvalSymlinks(filepath.Join(m.path("..v1"), "config.json"))
+	if target != want {
+		t.Errorf("Expected target %s, got %s", want, target)
+	}
+	if last := dirs[len(dirs)-1]; last != filepath.Dir(want) {
+		t.Errorf("Expected target directory %s to be watched, got %v", filepath.Dir(want), dirs)
+	}
+}
+
+func TestWithSymlinksConfigMapSwap(t *testing.T) {
+	m := newConfigMapDir(t, TestConfig{Name: "v1", Count: 1})
+
+	watcher, err := New(TestConfig{}, m.path("config.json"), WithSymlinks[TestConfig]())
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+	if got := watcher.Get(); got.Name != "v1" {
+		t.Fatalf("Expected v1, got %+v", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := watcher.SubscribeChanges(ctx)
+
+	m.swap("..v1", "..v2", TestConfig{Name: "v2", Count: 2})
+	if c := receiveChange(t, changes); c.New.Name != "v2" {
+		t.Errorf("Expected v2 after swap, got %+v", c.New)
+	}
+
+	m.swap("..v2", "..v3", TestConfig{Name: "v3", Count: 3})
+	if c := receiveChange(t, changes); c.New.Name != "v3" {
+		t.Errorf("Expected v3 after second swap, got %+v", c.New)
+	}
+}
+
+func TestWithSymlinksTargetWrite(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require privileges on Windows")
+	}
+	linkDir, targetDir := t.TempDir(), t.TempDir()
+	target := filepath.Join(targetDir, "real.json")
+	writeJSON(t, target, TestConfig{Name: "before"})
+	link := filepath.Join(linkDir, "config.json")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatalf("Symlink failed: %v", err)
+	}
+
+	watcher, err := New(TestConfig{}, link, WithSymlinks[TestConfig]())
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := watcher.SubscribeChanges(ctx)
+
+	writeJSON(t, target, TestConfig{Name: "after"})
+	if c := receiveChange(t, changes); c.New.Name != "after" {
+		t.Errorf("Expected write to target to reload, got %+v", c.New)
+	}
+}
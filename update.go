@@ -0,0 +1,85 @@
+package configwatcher
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// Snapshot returns the current value together with its revision, for use
+// with CompareAndSave. The pair is consistent or, under a concurrent
+// reload, pairs a newer value with an older revision, which only makes
+// CompareAndSave report a spurious conflict.
+func (w *Watcher[T]) Snapshot() (T, uint64) {
+	rev := w.revision.Load()
+	return w.Get(), rev
+}
+
+// Update applies fn to a copy of the latest configuration and saves the
+// result, all under the watcher's lock, so concurrent updates never lose
+// each other's changes. The file is re-read first, so edits made on disk
+// that have not been picked up yet are not overwritten. If fn returns an
+// error nothing is saved and the error is returned unchanged.
+func (w *Watcher[T]) Update(fn func(*T) error) error {
+	if err := w.writable(); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.withFileLock(func() error {
+		if err := w.refreshLocked(); err != nil {
+			return err
+		}
+		cfg, err := w.clone(w.Get())
+		if err != nil {
+			return err
+		}
+		if err := fn(&cfg); err != nil {
+			return err
+		}
+		return w.saveLocked(cfg)
+	})
+}
+
+// CompareAndSave saves cfg only if the configuration is still at revision
+// expected, as returned by Snapshot or Revision. It returns ErrConflict if
+// the in-memory value or the file changed in the meantime.
+func (w *Watcher[T]) CompareAndSave(expected uint64, cfg T) error {
+	if err := w.writable(); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.withFileLock(func() error {
+		if err := w.refreshLocked(); err != nil {
+			return err
+		}
+		if w.revision.Load() != expected {
+			return ErrConflict
+		}
+		return w.saveLocked(cfg)
+	})
+}
+
+// refreshLocked re-reads the file so that pending external edits are
+// committed before a read-modify-write. A missing file is not an error:
+// the write recreates it. Callers must hold w.mu.
+func (w *Watcher[T]) refreshLocked() error {
+	err := w.loadLocked(CauseFileEvent)
+	if err == nil || errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	w.sendError(err)
+	return err
+}
+
+// clone returns a deep copy of cfg via a round-trip through the codec, so
+// mutations never reach the stored value.
+func (w *Watcher[T]) clone(cfg T) (T, error) {
+	var out T
+	data, err := w.codec.Marshal(cfg)
+	if err != nil {
+		return out, err
+	}
+	err = w.codec.Unmarshal(data, &out)
+	return out, err
+}
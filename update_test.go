@@ -0,0 +1,113 @@
+package configwatcher
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestUpdateConcurrent(t *testing.T) {
+	watcher, configFile := newFileWatcher(t)
+
+	const workers, rounds = 8, 10
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range rounds {
+				if err := watcher.Update(func(c *TestConfig) error {
+					c.Count++
+					return nil
+				}); err != nil {
+					t.Errorf("Update failed: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := watcher.Get().Count; got != workers*rounds {
+		t.Errorf("Expected count %d, got %d", workers*rounds, got)
+	}
+	var onDisk TestConfig
+	data, _ := os.ReadFile(configFile)
+	if err := json.Unmarshal(data, &onDisk); err != nil || onDisk.Count != workers*rounds {
+		t.Errorf("Expected count %d on disk, got %q", workers*rounds, data)
+	}
+}
+
+func TestUpdateKeepsExternalEdit(t *testing.T) {
+	watcher, configFile := newFileWatcher(t)
+
+	// Edited on disk, not necessarily picked up by the watcher yet.
+	writeJSON(t, configFile, TestConfig{Name: "file", Count: 42})
+	if err := watcher.Update(func(c *TestConfig) error {
+		c.Name = "updated"
+		return nil
+	}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if got := watcher.Get(); got.Name != "updated" || got.Count != 42 {
+		t.Errorf("Expected external edit to be kept, got %+v", got)
+	}
+}
+
+func TestUpdateDoesNotMutateStoredValue(t *testing.T) {
+	watcher, _ := newFileWatcher(t)
+	errAbort := errors.New("abort")
+	rev := watcher.Revision()
+
+	err := watcher.Update(func(c *TestConfig) error {
+		c.Settings["a"] = "changed"
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Errorf("Expected fn error to be returned, got %v", err)
+	}
+	if got := watcher.Get().Settings["a"]; got != "1" {
+		t.Errorf("Aborted update leaked into stored value: %q", got)
+	}
+	if watcher.Revision() != rev {
+		t.Error("Aborted update must not change the revision")
+	}
+}
+
+func TestCompareAndSave(t *testing.T) {
+	watcher, configFile := newFileWatcher(t)
+
+	cfg, rev := watcher.Snapshot()
+	cfg.Count = 1
+	if err := watcher.CompareAndSave(rev, cfg); err != nil {
+		t.Fatalf("CompareAndSave failed: %v", err)
+	}
+
+	// The stale revision no longer matches.
+	cfg.Count = 2
+	if err := watcher.CompareAndSave(rev, cfg); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict after Save, got %v", err)
+	}
+
+	// Neither does one taken before an external edit.
+	cfg, rev = watcher.Snapshot()
+	writeJSON(t, configFile, TestConfig{Name: "external"})
+	cfg.Count = 3
+	if err := watcher.CompareAndSave(rev, cfg); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict after external edit, got %v", err)
+	}
+	if got := watcher.Get().Name; got != "external" {
+		t.Errorf("Expected external edit to win, got %q", got)
+	}
+}
+
+func TestUpdateReadOnly(t *testing.T) {
+	watcher, _ := newFileWatcher(t, WithReadOnly[TestConfig]())
+	if err := watcher.Update(func(*TestConfig) error { return nil }); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly from Update, got %v", err)
+	}
+	if err := watcher.CompareAndSave(watcher.Revision(), watcher.Get()); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly from CompareAndSave, got %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package configwatcher
+
+import "errors"
+
+// Validator is implemented by configuration types that can check their own
+// values. A Watcher calls Validate on every candidate configuration, on
+// either a value or pointer receiver, before accepting it.
+type Validator interface {
+	Validate() error
+}
+
+// WithValidator adds fn to the checks run on every candidate configuration:
+// the initial load, reloads after file changes and values passed to Save.
+// A candidate rejected by any check is reported as a *ValidationError and
+// the last good configuration is kept.
+func WithValidator[T any](fn func(T) error) Option[T] {
+	return func(w *Watcher[T]) { w.validators = append(w.validators, fn) }
+}
+
+// validate runs the Validator method of cfg, if any, and every registered
+// validator, returning a *ValidationError joining all failures.
+func (w *Watcher[T]) validate(cfg T) error {
+	var errs []error
+	if v, ok := any(cfg).(Validator); ok {
+		errs = append(errs, v.Validate())
+	} else if v, ok := any(&cfg).(Validator); ok {
+		errs = append(errs, v.Validate())
+	}
+	for _, fn := range w.validators {
+		errs = append(errs, fn(cfg))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return &ValidationError{Path: w.filename, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package configwatcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type validatedConfig struct {
+	Port int    `json:"port"`
+	DSN  string `json:"dsn"`
+}
+
+func (c validatedConfig) Validate() error {
+	if c.Port <= 0 {
+		return errors.New("port must be positive")
+	}
+	return nil
+}
+
+func writeJSON(t *testing.T, filename string, v any) {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Failed to marshal config: %v", err)
+	}
+	if err := os.WriteFile(filename, data, 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+}
+
+func TestValidateMethodRejectsInitialLoad(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	writeJSON(t, configFile, validatedConfig{Port: 0, DSN: "db"})
+
+	_, err := New(validatedConfig{Port: 8080}, configFile)
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) {
+		t.Fatalf("Expected *ValidationError, got %T: %v", err, err)
+	}
+	if vErr.Path != configFile {
+		t.Errorf("Expected path %s, got %s", configFile, vErr.Path)
+	}
+}
+
+func TestWithValidatorRejectsSave(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	writeJSON(t, configFile, validatedConfig{Port: 8080, DSN: "db"})
+
+	requireDSN := func(c validatedConfig) error {
+		if c.DSN == "" {
+			return errors.New("dsn is required")
+		}
+		return nil
+	}
+	watcher, err := New(validatedConfig{}, configFile, WithValidator(requireDSN))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	err = watcher.Save(validatedConfig{Port: 9090})
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) {
+		t.Fatalf("Expected *ValidationError, got %T: %v", err, err)
+	}
+
+	if got := watcher.Get(); got.Port != 8080 || got.DSN != "db" {
+		t.Errorf("Expected last good config, got %+v", got)
+	}
+	var onDisk validatedConfig
+	data, _ := os.ReadFile(configFile)
+	_ = json.Unmarshal(data, &onDisk)
+	if onDisk.Port != 8080 {
+		t.Errorf("Rejected config must not be written, file has %+v", onDisk)
+	}
+}
+
+func TestInvalidReloadKeepsLastGood(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	writeJSON(t, configFile, validatedConfig{Port: 8080, DSN: "db"})
+
+	errChan := make(chan error, 10)
+	watcher, err := New(validatedConfig{}, configFile, WithErrorChan[validatedConfig](errChan))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer watcher.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates := watcher.Subscribe(ctx)
+
+	writeJSON(t, configFile, validatedConfig{Port: 0, DSN: "other"})
+
+	select {
+	case err := <-errChan:
+		var vErr *ValidationError
+		if !errors.As(err, &vErr) {
+			t.Errorf("Expected *ValidationError, got %T: %v", err, err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for validation error")
+	}
+
+	select {
+	case <-updates:
+		t.Error("Invalid config must not be broadcast")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if got := watcher.Get(); got.Port != 8080 || got.DSN != "db" {
+		t.Errorf("Expected last good config, got %+v", got)
+	}
+}
@@ -0,0 +1,100 @@
+package configwatcher
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	"github.com/blackorder/chanhub"
+)
+
+// SubscribePath returns a channel that signals when the value at path, or
+// anything below it, changes. Paths use the dotted form reported in
+// FieldChange.Path, such as "server" or "server.port". The channel is
+// closed when ctx is done or the watcher is closed.
+func (w *Watcher[T]) SubscribePath(ctx context.Context, path string) <-chan struct{} {
+	ctx = w.scope(ctx)
+	ch := make(chan struct{}, 1)
+	remove := w.addListener(func(c Change[T]) {
+		if !touches(c.Delta, path) {
+			return
+		}
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	})
+	context.AfterFunc(ctx, func() {
+		remove()
+		close(ch)
+	})
+	return ch
+}
+
+// touches reports whether any change in d affects path: the path itself,
+// a value below it, or a parent replaced as a whole.
+func touches(d Delta, path string) bool {
+	for _, c := range d {
+		if within(c.Path, path) || within(path, c.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// within reports whether path equals prefix or lies below it.
+func within(path, prefix string) bool {
+	if prefix == "" || path == prefix {
+		return true
+	}
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	next := path[len(prefix)]
+	return next == '.' || next == '['
+}
+
+// View is a read-only watcher of a value derived from a Watcher's
+// configuration. It only notifies subscribers when the derived value
+// actually changes. A View lives as long as its Watcher.
+type View[U any] struct {
+	hub   *chanhub.Hub
+	value atomic.Value
+	scope func(context.Context) context.Context
+}
+
+// Select returns a View of the part of w's configuration returned by fn.
+// fn is called with every new configuration; subscribers of the View are
+// notified only when its result differs from the previous one.
+//
+//	server := configwatcher.Select(w, func(c AppConfig) ServerConfig { return c.Server })
+func Select[T, U any](w *Watcher[T], fn func(T) U) *View[U] {
+	v := &View[U]{hub: chanhub.New(), scope: w.scope}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	v.value.Store(fn(w.Get()))
+	remove := w.addListener(func(c Change[T]) {
+		next := fn(c.New)
+		if reflect.DeepEqual(v.Get(), next) {
+			return
+		}
+		v.value.Store(next)
+		v.hub.Broadcast()
+	})
+	context.AfterFunc(w.ctx, remove)
+	return v
+}
+
+// Get returns the current derived value.
+func (v *View[U]) Get() U {
+	return v.value.Load().(U)
+}
+
+// Subscribe returns a channel that signals when the derived value changes.
+// The channel is closed when ctx is done or the underlying watcher is
+// closed.
+func (v *View[U]) Subscribe(ctx context.Context) <-chan struct{} {
+	return v.hub.Subscribe(v.scope(ctx))
+}
@@ -0,0 +1,130 @@
+package configwatcher
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type viewConfig struct {
+	Server   diffServer `json:"server"`
+	Database struct {
+		DSN string `json:"dsn"`
+	} `json:"database"`
+}
+
+func expectSignal(t *testing.T, ch <-chan struct{}, want bool) {
+	t.Helper()
+	select {
+	case <-ch:
+		if !want {
+			t.Error("Unexpected notification")
+		}
+	case <-time.After(200 * time.Millisecond):
+		if want {
+			t.Error("Expected notification")
+		}
+	}
+}
+
+// viewFile returns the configuration written for view tests.
+func viewFile() viewConfig {
+	var cfg viewConfig
+	cfg.Server = diffServer{Host: "localhost", Port: 8080}
+	cfg.Database.DSN = "postgres://db"
+	return cfg
+}
+
+func TestSubscribePath(t *testing.T) {
+	watcher, _ := newTestWatcher(t, viewConfig{}, viewFile())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	port := watcher.SubscribePath(ctx, "server.port")
+	server := watcher.SubscribePath(ctx, "server")
+	database := watcher.SubscribePath(ctx, "database")
+
+	cfg := watcher.Get()
+	cfg.Server.Port = 9090
+	if err := watcher.Save(cfg); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	expectSignal(t, port, true)
+	expectSignal(t, server, true)
+	expectSignal(t, database, false)
+
+	cfg.Server.Host = "example.com"
+	if err := watcher.Save(cfg); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	expectSignal(t, port, false)
+	expectSignal(t, server, true)
+
+	cancel()
+	select {
+	case _, ok := <-port:
+		if ok {
+			t.Error("Expected path channel to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for path channel to close")
+	}
+}
+
+func TestWithin(t *testing.T) {
+	tests := []struct {
+		path, prefix string
+		want         bool
+	}{
+		{"server.port", "server", true},
+		{"server", "server", true},
+		{"servers.port", "server", false},
+		{"hosts[1]", "hosts", true},
+		{"server", "server.port", false},
+		{"anything", "", true},
+	}
+	for _, tt := range tests {
+		if got := within(tt.path, tt.prefix); got != tt.want {
+			t.Errorf("within(%q, %q) = %v, want %v", tt.path, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	watcher, _ := newTestWatcher(t, viewConfig{}, viewFile())
+	view := Select(watcher, func(c viewConfig) diffServer { return c.Server })
+
+	if got := view.Get(); got.Port != 8080 {
+		t.Errorf("Expected initial derived value, got %+v", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates := view.Subscribe(ctx)
+
+	cfg := watcher.Get()
+	cfg.Database.DSN = "postgres://other"
+	if err := watcher.Save(cfg); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	expectSignal(t, updates, false)
+
+	cfg.Server.Port = 9090
+	if err := watcher.Save(cfg); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	expectSignal(t, updates, true)
+	if got := view.Get(); got.Port != 9090 {
+		t.Errorf("Expected updated derived value, got %+v", got)
+	}
+
+	watcher.Close()
+	select {
+	case _, ok := <-updates:
+		if ok {
+			t.Error("Expected view channel to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for view channel to close")
+	}
+}